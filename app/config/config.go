@@ -4,18 +4,23 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"axcommutator/app/templates"
+
+	"go.uber.org/zap"
 )
 
 type ServiceConfig struct {
-	Name                       string
-	SMTP                       SMTPConfig
-	Telegram                   TelegramConfig
-	EmailTemplates             map[string]EmailTemplate
-	TelegramTemplates          map[string]string
-	TelegramTemplatePaths      map[string]string
-	EmailTemplateSubjectPaths  map[string]string
-	EmailTemplateBodyPaths     map[string]string
-	SupportedLangs             []string
+	Name                      string
+	SMTP                      SMTPConfig
+	Telegram                  TelegramConfig
+	EmailTemplates            map[string]EmailTemplate
+	TelegramTemplates         map[string]string
+	TelegramTemplatePaths     map[string]string
+	EmailTemplateSubjectPaths map[string]string
+	EmailTemplateBodyPaths    map[string]string
+	SupportedLangs            []string
+	Messengers                []string
 }
 
 type SMTPConfig struct {
@@ -25,6 +30,14 @@ type SMTPConfig struct {
 	Port     string
 	From     string
 	Admin    string
+	// AuthMethod selects the SMTP auth mechanism: "plain" (default), "login",
+	// "cram-md5" or "xoauth2".
+	AuthMethod string
+	// DKIM signing is optional; outbound mail is signed only when all three
+	// fields are set.
+	DKIMPrivateKeyPath string
+	DKIMSelector       string
+	DKIMDomain         string
 }
 
 type TelegramConfig struct {
@@ -43,7 +56,7 @@ func (tc TelegramConfig) Configured() bool {
 
 var services = map[string]ServiceConfig{}
 
-func LoadServices() {
+func LoadServices(logger *zap.Logger) {
 	for _, env := range os.Environ() {
 		if strings.Contains(env, "SERVICE_NAME") {
 			// fmt.Printf("🌍 RAW ENV: [%q]\n", env)
@@ -74,32 +87,44 @@ func LoadServices() {
 				// fmt.Printf("🌐 Supported langs for %s: %v\n", name, langs)
 			}
 
-			emailTemplates := loadEmailTemplates(prefix, name, langs)
+			messengers := strings.Split(os.Getenv(prefix+"_MESSENGERS"), ",")
+			if len(messengers) == 0 || (len(messengers) == 1 && messengers[0] == "") {
+				messengers = []string{"email", "telegram"}
+			}
+
+			overrideDir := os.Getenv("AXC_TEMPLATE_OVERRIDE_DIR")
+
+			emailTemplates := loadEmailTemplates(logger, prefix, name, langs, overrideDir)
 			emailSubjectPaths := loadEmailSubjectPaths(prefix, name, langs)
 			emailBodyPaths := loadEmailBodyPaths(prefix, name, langs)
-			tgTemplates := loadTelegramTemplates(prefix, name, langs)
+			tgTemplates := loadTelegramTemplates(logger, prefix, name, langs, overrideDir)
 			tgPaths := loadTelegramPaths(prefix, name, langs)
 
 			services[strings.ToLower(name)] = ServiceConfig{
 				Name: name,
 				SMTP: SMTPConfig{
-					User:     os.Getenv(prefix + "_SMTP_USER"),
-					Password: os.Getenv(prefix + "_SMTP_PASS"),
-					Host:     os.Getenv(prefix + "_SMTP_HOST"),
-					Port:     os.Getenv(prefix + "_SMTP_PORT"),
-					From:     os.Getenv(prefix + "_FROM_EMAIL"),
-					Admin:    os.Getenv(prefix + "_ADMIN_EMAIL"),
+					User:               os.Getenv(prefix + "_SMTP_USER"),
+					Password:           os.Getenv(prefix + "_SMTP_PASS"),
+					Host:               os.Getenv(prefix + "_SMTP_HOST"),
+					Port:               os.Getenv(prefix + "_SMTP_PORT"),
+					From:               os.Getenv(prefix + "_FROM_EMAIL"),
+					Admin:              os.Getenv(prefix + "_ADMIN_EMAIL"),
+					AuthMethod:         strings.ToLower(os.Getenv(prefix + "_SMTP_AUTH")),
+					DKIMPrivateKeyPath: os.Getenv(prefix + "_DKIM_PRIVATE_KEY_PATH"),
+					DKIMSelector:       os.Getenv(prefix + "_DKIM_SELECTOR"),
+					DKIMDomain:         os.Getenv(prefix + "_DKIM_DOMAIN"),
 				},
 				Telegram: TelegramConfig{
 					BotToken: os.Getenv(prefix + "_TG_BOT_TOKEN"),
 					ChatID:   os.Getenv(prefix + "_TG_CHAT_ID"),
 				},
-				EmailTemplates:             emailTemplates,
-				EmailTemplateSubjectPaths:  emailSubjectPaths,
-				EmailTemplateBodyPaths:     emailBodyPaths,
-				TelegramTemplates:          tgTemplates,
-				TelegramTemplatePaths:      tgPaths,
-				SupportedLangs:             langs,
+				EmailTemplates:            emailTemplates,
+				EmailTemplateSubjectPaths: emailSubjectPaths,
+				EmailTemplateBodyPaths:    emailBodyPaths,
+				TelegramTemplates:         tgTemplates,
+				TelegramTemplatePaths:     tgPaths,
+				SupportedLangs:            langs,
+				Messengers:                messengers,
 			}
 
 			// fmt.Printf("✅ Loaded service: %s with %d email template(s), %d tg template(s)\n\n",
@@ -118,64 +143,66 @@ func GetService(name string) (ServiceConfig, bool) {
 	return svc, ok
 }
 
-func loadEmailTemplates(prefix string, service string, langs []string) map[string]EmailTemplate {
-	templates := make(map[string]EmailTemplate)
+// loadEmailTemplates resolves each language's subject and body through the
+// layered fallback env body -> env path -> override dir -> embedded default,
+// logging the layer each one resolved from.
+func loadEmailTemplates(logger *zap.Logger, prefix string, service string, langs []string, overrideDir string) map[string]EmailTemplate {
+	result := make(map[string]EmailTemplate)
 
 	for _, lang := range langs {
 		lang = strings.TrimSpace(strings.ToLower(lang))
-		subjectKey := fmt.Sprintf("%s_EMAIL_SUBJECT_%s", prefix, strings.ToUpper(lang))
-		bodyKey := fmt.Sprintf("%s_EMAIL_BODY_%s", prefix, strings.ToUpper(lang))
-		bodyPathKey := bodyKey + "_PATH"
-
-		subject := os.Getenv(subjectKey)
-		body := os.Getenv(bodyKey)
-
-		if body == "" {
-			path := os.Getenv(bodyPathKey)
-			if path != "" {
-				content, err := os.ReadFile(path)
-				if err != nil {
-					// fmt.Printf("⚠️ [%s:%s] Failed to load email body from file: %v\n", service, lang, err)
-				} else {
-					body = string(content)
-					// fmt.Printf("📄 [%s:%s] Loaded email body from %s\n", service, lang, path)
-				}
-			} else {
-				// fmt.Printf("⚠️ [%s:%s] No body or path provided for email\n", service, lang)
-			}
-		} else {
-			// fmt.Printf("📝 [%s:%s] Loaded email body from env\n", service, lang)
+		subjectEnvBody := os.Getenv(fmt.Sprintf("%s_EMAIL_SUBJECT_%s", prefix, strings.ToUpper(lang)))
+		subjectEnvPath := os.Getenv(fmt.Sprintf("%s_EMAIL_SUBJECT_%s_PATH", prefix, strings.ToUpper(lang)))
+		bodyEnvBody := os.Getenv(fmt.Sprintf("%s_EMAIL_BODY_%s", prefix, strings.ToUpper(lang)))
+		bodyEnvPath := os.Getenv(fmt.Sprintf("%s_EMAIL_BODY_%s_PATH", prefix, strings.ToUpper(lang)))
+
+		subject, subjectSource, err := templates.Resolve("email_subject", lang, subjectEnvBody, subjectEnvPath, overrideDir)
+		if err != nil && logger != nil {
+			logger.Warn("failed to resolve email subject template", zap.String("service", service), zap.String("lang", lang), zap.Error(err))
+		}
+		body, bodySource, err := templates.Resolve("email_body", lang, bodyEnvBody, bodyEnvPath, overrideDir)
+		if err != nil && logger != nil {
+			logger.Warn("failed to resolve email body template", zap.String("service", service), zap.String("lang", lang), zap.Error(err))
 		}
 
-		templates[lang] = EmailTemplate{
+		if logger != nil {
+			logger.Info("resolved email template",
+				zap.String("service", service), zap.String("lang", lang),
+				zap.String("subject_source", string(subjectSource)), zap.String("body_source", string(bodySource)))
+		}
+
+		result[lang] = EmailTemplate{
 			Subject: subject,
 			Body:    body,
 		}
 	}
-	return templates
+	return result
 }
 
-func loadTelegramTemplates(prefix string, service string, langs []string) map[string]string {
-	templates := make(map[string]string)
+// loadTelegramTemplates resolves each language's message through the
+// layered fallback env path -> override dir -> embedded default (Telegram
+// has no separate "env body" variable, unlike email).
+func loadTelegramTemplates(logger *zap.Logger, prefix string, service string, langs []string, overrideDir string) map[string]string {
+	result := make(map[string]string)
 
 	for _, lang := range langs {
 		lang = strings.TrimSpace(strings.ToLower(lang))
-		key := fmt.Sprintf("%s_TG_MSG_%s_PATH", prefix, strings.ToUpper(lang))
-		path := os.Getenv(key)
+		envPath := os.Getenv(fmt.Sprintf("%s_TG_MSG_%s_PATH", prefix, strings.ToUpper(lang)))
 
-		if path != "" {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				// fmt.Printf("⚠️ [%s:%s] Failed to load telegram template from %s: %v\n", service, lang, path, err)
-			} else {
-				templates[lang] = string(content)
-				// fmt.Printf("📨 [%s:%s] Loaded Telegram template from %s\n", service, lang, path)
-			}
-		} else {
-			// fmt.Printf("⚠️ [%s:%s] No telegram path provided\n", service, lang)
+		content, source, err := templates.Resolve("telegram", lang, "", envPath, overrideDir)
+		if err != nil && logger != nil {
+			logger.Warn("failed to resolve telegram template", zap.String("service", service), zap.String("lang", lang), zap.Error(err))
+		}
+		if logger != nil {
+			logger.Info("resolved telegram template",
+				zap.String("service", service), zap.String("lang", lang), zap.String("source", string(source)))
+		}
+
+		if content != "" {
+			result[lang] = content
 		}
 	}
-	return templates
+	return result
 }
 
 func loadEmailSubjectPaths(prefix string, service string, langs []string) map[string]string {
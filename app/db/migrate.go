@@ -0,0 +1,361 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a single paired up/down SQL migration.
+type Migration struct {
+	Version  int
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string // sha256 of UpSQL, recorded so a changed file is caught
+}
+
+// StatusEntry describes one migration's applied/pending state for `migrate status`.
+type StatusEntry struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// migrationsSource returns the filesystem migrations are read from: the
+// directory named by MIGRATIONS_DIR if set, otherwise the set embedded
+// into the binary at build time, so the container no longer depends on
+// /app/migrations being mounted.
+func migrationsSource() (fs.FS, string, error) {
+	if dir := os.Getenv("MIGRATIONS_DIR"); dir != "" {
+		return os.DirFS(dir), dir, nil
+	}
+	sub, err := fs.Sub(embeddedMigrations, "migrations")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+	return sub, "embedded", nil
+}
+
+func loadMigrations() ([]Migration, string, error) {
+	fsys, source, err := migrationsSource()
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		name, direction := m[2], m[3]
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		}
+		if direction == "up" {
+			mig.UpSQL = string(content)
+			sum := sha256.Sum256(content)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, source, nil
+}
+
+func ensureMigrationsTable(db *sql.DB) error {
+	if _, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS migrations (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            name TEXT NOT NULL UNIQUE,
+            applied_at DATETIME NOT NULL,
+            checksum TEXT NOT NULL DEFAULT ''
+        )
+    `); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	// Pre-existing databases created before the checksum column was added
+	// won't have it yet; ALTER TABLE fails harmlessly if it's already there.
+	_, _ = db.Exec(`ALTER TABLE migrations ADD COLUMN checksum TEXT NOT NULL DEFAULT ''`)
+
+	return nil
+}
+
+// applyMigrations applies pending migrations, called once from InitDB.
+func applyMigrations(logger *zap.Logger) error {
+	return MigrateUp(db, logger)
+}
+
+// MigrateUp applies all pending migrations in order, each in its own
+// transaction so a failure in a later file doesn't roll back earlier,
+// already-committed ones. A previously-applied file whose content (and
+// therefore checksum) has since changed aborts the run unless
+// MIGRATIONS_ALLOW_CHECKSUM_MISMATCH=true is set.
+func MigrateUp(conn *sql.DB, logger *zap.Logger) error {
+	if err := ensureMigrationsTable(conn); err != nil {
+		return err
+	}
+
+	migrations, source, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	allowMismatch := os.Getenv("MIGRATIONS_ALLOW_CHECKSUM_MISMATCH") == "true"
+
+	var appliedCount, skippedCount int
+	for _, mig := range migrations {
+		filename := fmt.Sprintf("%03d_%s.up.sql", mig.Version, mig.Name)
+
+		var storedChecksum string
+		err := conn.QueryRow("SELECT checksum FROM migrations WHERE name = ?", filename).Scan(&storedChecksum)
+		switch {
+		case err == sql.ErrNoRows:
+			// not yet applied, fall through
+		case err != nil:
+			return fmt.Errorf("failed to check migration %s: %w", filename, err)
+		default:
+			if storedChecksum != "" && storedChecksum != mig.Checksum && !allowMismatch {
+				return fmt.Errorf("migration %s has changed since it was applied (checksum mismatch); "+
+					"set MIGRATIONS_ALLOW_CHECKSUM_MISMATCH=true to override", filename)
+			}
+			skippedCount++
+			continue
+		}
+
+		start := time.Now()
+		if err := applyOne(conn, filename, mig.UpSQL, mig.Checksum); err != nil {
+			return err
+		}
+		logger.Info("Applied migration",
+			zap.String("file", filename),
+			zap.String("source", source),
+			zap.Duration("duration", time.Since(start)))
+		appliedCount++
+	}
+
+	if appliedCount == 0 {
+		logger.Info("All migrations up to date", zap.Int("skipped", skippedCount), zap.String("source", source))
+	} else {
+		logger.Info("Migration completed",
+			zap.Int("applied", appliedCount),
+			zap.Int("skipped", skippedCount),
+			zap.String("source", source))
+	}
+
+	return nil
+}
+
+func applyOne(conn *sql.DB, filename, sqlText, checksum string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", filename, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(sqlText); err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", filename, err)
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO migrations (name, applied_at, checksum) VALUES (?, ?, ?)
+		 ON CONFLICT(name) DO UPDATE SET checksum = excluded.checksum`,
+		filename, time.Now().UTC(), checksum,
+	); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", filename, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", filename, err)
+	}
+	return nil
+}
+
+// MigrateDown reverts the most recently applied `steps` migrations, each
+// in its own transaction, most recent first.
+func MigrateDown(conn *sql.DB, logger *zap.Logger, steps int) error {
+	if err := ensureMigrationsTable(conn); err != nil {
+		return err
+	}
+
+	migrations, source, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	byFilename := make(map[string]Migration, len(migrations))
+	for _, mig := range migrations {
+		byFilename[fmt.Sprintf("%03d_%s.up.sql", mig.Version, mig.Name)] = mig
+	}
+
+	rows, err := conn.Query("SELECT name FROM migrations ORDER BY id DESC LIMIT ?", steps)
+	if err != nil {
+		return fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	var applied []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied = append(applied, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading applied migrations: %w", err)
+	}
+
+	var revertedCount int
+	for _, filename := range applied {
+		mig, ok := byFilename[filename]
+		if !ok || mig.DownSQL == "" {
+			return fmt.Errorf("no down migration found for %s", filename)
+		}
+
+		start := time.Now()
+		tx, err := conn.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction for %s: %w", filename, err)
+		}
+		if _, err := tx.Exec(mig.DownSQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to revert migration %s: %w", filename, err)
+		}
+		if _, err := tx.Exec("DELETE FROM migrations WHERE name = ?", filename); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to unrecord migration %s: %w", filename, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit rollback of %s: %w", filename, err)
+		}
+
+		logger.Info("Reverted migration",
+			zap.String("file", filename),
+			zap.String("source", source),
+			zap.Duration("duration", time.Since(start)))
+		revertedCount++
+	}
+
+	logger.Info("Migration rollback completed", zap.Int("reverted", revertedCount))
+	return nil
+}
+
+// Status reports the applied/pending state of every known migration.
+func Status(conn *sql.DB) ([]StatusEntry, error) {
+	if err := ensureMigrationsTable(conn); err != nil {
+		return nil, err
+	}
+
+	migrations, _, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[string]time.Time)
+	rows, err := conn.Query("SELECT name, applied_at FROM migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var at time.Time
+		if err := rows.Scan(&name, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan migration status: %w", err)
+		}
+		appliedAt[name] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading migration status: %w", err)
+	}
+
+	entries := make([]StatusEntry, 0, len(migrations))
+	for _, mig := range migrations {
+		filename := fmt.Sprintf("%03d_%s.up.sql", mig.Version, mig.Name)
+		at, ok := appliedAt[filename]
+		entries = append(entries, StatusEntry{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			Applied:   ok,
+			AppliedAt: at,
+		})
+	}
+	return entries, nil
+}
+
+// NewMigration scaffolds an empty up/down pair in dir (normally the
+// on-disk app/db/migrations directory — embed.FS is read-only, so this
+// only makes sense when run against the source tree, not the binary).
+func NewMigration(dir, name string) (upPath, downPath string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	next := 1
+	for _, entry := range entries {
+		m := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		if version >= next {
+			next = version + 1
+		}
+	}
+
+	upPath = fmt.Sprintf("%s/%03d_%s.up.sql", dir, next, name)
+	downPath = fmt.Sprintf("%s/%03d_%s.down.sql", dir, next, name)
+
+	if err := os.WriteFile(upPath, []byte("-- up migration\n"), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", upPath, err)
+	}
+	if err := os.WriteFile(downPath, []byte("-- down migration\n"), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", downPath, err)
+	}
+	return upPath, downPath, nil
+}
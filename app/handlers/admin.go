@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"axcommutator/app/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// Limiter is the process-wide rate limiter, wired up in main.go so the
+// admin endpoints can manage the same ban list the middleware enforces.
+var Limiter *utils.RateLimiter
+
+type banRequest struct {
+	IP     string `json:"ip"`
+	Reason string `json:"reason"`
+}
+
+// HandleAdminListBans returns every currently-active IP ban.
+func HandleAdminListBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := Limiter.ListBans()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list bans")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, bans)
+}
+
+// HandleAdminBanIP bans the IP in the request body.
+func HandleAdminBanIP(w http.ResponseWriter, r *http.Request) {
+	var req banRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IP == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing or invalid ip")
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "manual ban"
+	}
+
+	if err := Limiter.Ban(req.IP, req.Reason); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to ban IP")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "banned", "ip": req.IP})
+}
+
+// HandleAdminUnbanIP removes the ban on {ip}.
+func HandleAdminUnbanIP(w http.ResponseWriter, r *http.Request) {
+	ip := mux.Vars(r)["ip"]
+	if ip == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing ip")
+		return
+	}
+
+	if err := Limiter.Unban(ip); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to unban IP")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "unbanned", "ip": ip})
+}
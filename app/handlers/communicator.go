@@ -3,24 +3,46 @@ package handlers
 import (
 	"axcommutator/app/config"
 	"axcommutator/app/db"
+	"axcommutator/app/idempotency"
+	"axcommutator/app/messenger"
+	"axcommutator/app/templates"
 	"axcommutator/app/utils"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"go.uber.org/zap"
 )
 
+// Logger is the process-wide logger, wired up in main.go so handlers can
+// surface dispatch failures without changing every handler's signature.
+var Logger *zap.Logger
+
+// Idempotency rejects duplicate order submissions, wired up in main.go.
+var Idempotency *idempotency.Store
+
+// Files stores and expires uploaded attachments, wired up in main.go.
+var Files *utils.FileStore
+
+// Renderer renders subject/body templates with conditionals and helper
+// funcs instead of naive "{key}" substitution. It holds no state, so one
+// shared instance is enough for every request.
+var Renderer = templates.NewRenderer()
+
 type ProjectOrder struct {
-	FullName        string `json:"fullName"`
-	CompanyName     string `json:"companyName,omitempty"`
-	Country         string `json:"country,omitempty"`
-	Address         string `json:"address,omitempty"`
-	ContactInfo     string `json:"contactInfo"`
-	ProjectLink     string `json:"projectLink,omitempty"`
-	PaymentMethod   string `json:"paymentMethod"`
-	StartDate       string `json:"startDate"`
-	Language 		string `json:"language"`
+	FullName         string `json:"fullName"`
+	CompanyName      string `json:"companyName,omitempty"`
+	Country          string `json:"country,omitempty"`
+	Address          string `json:"address,omitempty"`
+	ContactInfo      string `json:"contactInfo"`
+	ProjectLink      string `json:"projectLink,omitempty"`
+	PaymentMethod    string `json:"paymentMethod"`
+	StartDate        string `json:"startDate"`
+	Language         string `json:"language"`
 	BriefFile        []byte `json:"briefFile,omitempty"`
 	SpecificationPdf []byte `json:"specificationPdf"`
 	InvoicePdf       []byte `json:"invoicePdf"`
@@ -67,7 +89,7 @@ func HandleProjectOrder(w http.ResponseWriter, r *http.Request) {
 	}
 	if lang == "" {
 		lang = "en"
-	}	
+	}
 
 	service, ok := config.GetService(serviceName)
 	if !ok {
@@ -83,6 +105,24 @@ func HandleProjectOrder(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var fingerprint string
+	if Idempotency != nil {
+		fingerprint = idempotency.Fingerprint(serviceName, order.FullName, order.ContactInfo, order.PaymentMethod, order.SpecificationPdf, order.ContractPdf)
+		idempotencyKey := r.Header.Get("Idempotency-Key")
+
+		dup, err := Idempotency.Check(fingerprint, idempotencyKey)
+		if err != nil && Logger != nil {
+			Logger.Error("idempotency check failed", zap.Error(err))
+		}
+		if dup != nil {
+			respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error":      "Duplicate order submission",
+				"first_seen": dup.FirstSeen.Format(time.RFC3339),
+			})
+			return
+		}
+	}
+
 	filePaths, err := saveOrderFiles(order)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to save files")
@@ -104,28 +144,103 @@ func HandleProjectOrder(w http.ResponseWriter, r *http.Request) {
 	subject := "Order Confirmation"
 	body := "Order received VibeCoders Club by Aleksandr Shaman - www.codcl.com"
 
-	// шаблон темы письма
+	// шаблон темы письма — a path on disk takes priority (hot-reloadable
+	// without a restart); otherwise fall back to whatever LoadServices
+	// already resolved (env body, override dir, or embedded default).
+	rawSubject := ""
 	if path := service.EmailTemplateSubjectPaths[lang]; path != "" {
 		if raw, err := os.ReadFile(path); err == nil {
-			subject = utils.FillTemplate(string(raw), templateData)
+			rawSubject = string(raw)
+		}
+	}
+	if rawSubject == "" {
+		rawSubject = service.EmailTemplates[lang].Subject
+	}
+	if rawSubject != "" {
+		if rendered, _, err := Renderer.Render("email-subject", rawSubject, lang, templateData); err == nil {
+			subject = rendered
+		} else if Logger != nil {
+			Logger.Warn("failed to render email subject template", zap.Error(err))
 		}
 	}
 
-	// шаблон тела письма
+	// шаблон тела письма — an HTML template (starting with "<") is sent as
+	// the HTML alternative, anything else as the plain-text body.
+	var htmlBody string
+	rawBody := ""
 	if path := service.EmailTemplateBodyPaths[lang]; path != "" {
 		if raw, err := os.ReadFile(path); err == nil {
-			body = utils.FillTemplate(string(raw), templateData)
+			rawBody = string(raw)
+		}
+	}
+	if rawBody == "" {
+		rawBody = service.EmailTemplates[lang].Body
+	}
+	if rawBody != "" {
+		var rendered string
+		var renderErr error
+		if strings.HasPrefix(strings.TrimSpace(rawBody), "<") {
+			rendered, _, renderErr = Renderer.RenderHTML("email-body", rawBody, lang, templateData)
+			if renderErr == nil {
+				htmlBody = rendered
+			}
+		} else {
+			rendered, _, renderErr = Renderer.Render("email-body", rawBody, lang, templateData)
+			if renderErr == nil {
+				body = rendered
+			}
+		}
+		if renderErr != nil && Logger != nil {
+			Logger.Warn("failed to render email body template", zap.Error(renderErr))
+		}
+	}
+
+	var msgAttachments []messenger.Attachment
+	if attachments, err := utils.PrepareAttachments(filePaths); err == nil {
+		for _, a := range attachments {
+			msgAttachments = append(msgAttachments, messenger.Attachment{
+				Name:    a.Name,
+				Content: a.Content,
+				MIME:    a.MIME,
+			})
 		}
 	}
 
-	attachments, err := utils.PrepareAttachments(filePaths)
-	if err == nil {
-		_ = utils.SendOrderEmail(service, subject, body, order.ContactInfo, attachments)
+	// A verified Telegram user is keyed in the recipient store by the same
+	// contact info they submit orders under, so a notification reaches
+	// their own chat instead of the service's static, shared chat ID.
+	var chatID string
+	if TelegramBot != nil {
+		if recipient, ok := TelegramBot.Recipient(order.ContactInfo); ok {
+			chatID = strconv.FormatInt(recipient.ChatID, 10)
+		}
 	}
 
-	_ = utils.SendTelegramNotification(service, lang, templateData)
+	mgr := messenger.BuildManager(service, Logger)
+	mgr.Dispatch(r.Context(), service.Messengers,
+		messenger.Message{
+			Recipient:   order.ContactInfo,
+			Subject:     subject,
+			Body:        body,
+			HTML:        htmlBody,
+			Data:        templateData,
+			Attachments: msgAttachments,
+		},
+		messenger.Notification{
+			Lang:   lang,
+			Data:   templateData,
+			ChatID: chatID,
+		},
+	)
+
 	_ = logOrderToDB(r, serviceName, lang, order)
 
+	if Idempotency != nil {
+		if err := Idempotency.Record(fingerprint, r.Header.Get("Idempotency-Key")); err != nil && Logger != nil {
+			Logger.Error("failed to record order fingerprint", zap.Error(err))
+		}
+	}
+
 	respondWithJSON(w, http.StatusOK, map[string]string{
 		"status":  "success",
 		"service": serviceName,
@@ -156,7 +271,7 @@ func saveOrderFiles(order ProjectOrder) (map[string]string, error) {
 		files["brief"] = order.BriefFile
 	}
 	for name, content := range files {
-		fileInfo, err := utils.SaveTempFile(content, name)
+		fileInfo, err := Files.Save(content, name, "application/pdf")
 		if err != nil {
 			for _, path := range filePaths {
 				_ = os.Remove(path)
@@ -196,7 +311,6 @@ func logOrderToDB(r *http.Request, serviceName, lang string, order ProjectOrder)
 	return err
 }
 
-
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{
 		"status": "healthy",
@@ -252,4 +366,4 @@ func logConsentToDB(consent CookieConsent) error {
 		consent.Timestamp,
 	)
 	return err
-}
\ No newline at end of file
+}
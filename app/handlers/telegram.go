@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"axcommutator/app/telegram"
+
+	"github.com/gorilla/mux"
+)
+
+// pinTTL is how long a PIN minted by HandleTelegramGeneratePIN stays valid
+// for a user to send to the bot before it expires unverified.
+const pinTTL = 10 * time.Minute
+
+// TelegramBot is the process-wide Telegram bot, wired up in main.go so
+// handlers can poll it for PIN verification without changing every
+// handler's signature.
+var TelegramBot *telegram.Bot
+
+// telegramPINRequest is the payload for HandleTelegramGeneratePIN.
+type telegramPINRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// HandleTelegramGeneratePIN mints a short-lived PIN for the requesting
+// app user, which the frontend shows them to send to the bot; the caller
+// then polls HandleTelegramVerify with the same pin.
+func HandleTelegramGeneratePIN(w http.ResponseWriter, r *http.Request) {
+	if TelegramBot == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Telegram bot not configured")
+		return
+	}
+
+	var req telegramPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.UserID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing user_id")
+		return
+	}
+
+	pin, err := TelegramBot.GenerateVerificationPIN(req.UserID, pinTTL)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to generate PIN")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"pin":        pin,
+		"expires_in": int(pinTTL.Seconds()),
+	})
+}
+
+// HandleTelegramVerify reports whether {pin} has been verified yet by a
+// Telegram user, and if so the chat ID it can now be reached on.
+func HandleTelegramVerify(w http.ResponseWriter, r *http.Request) {
+	if TelegramBot == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Telegram bot not configured")
+		return
+	}
+
+	pin := mux.Vars(r)["pin"]
+	if pin == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing pin")
+		return
+	}
+
+	chatID, ok := TelegramBot.Verified(pin)
+	if !ok {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"verified": false})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"verified": true,
+		"chat_id":  chatID,
+	})
+}
+
+// HandleTelegramWebhook receives inbound updates when the bot is run in
+// webhook mode instead of long-polling (TELEGRAM_WEBHOOK=true, see main.go).
+func HandleTelegramWebhook(w http.ResponseWriter, r *http.Request) {
+	if TelegramBot == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Telegram bot not configured")
+		return
+	}
+	TelegramBot.HandleWebhook(w, r)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"axcommutator/app/config"
+	"axcommutator/app/templates"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleTemplatePreview renders a configured template without sending
+// anything, returning both the rendered output and the variable list the
+// template referenced so a frontend can generate a form dynamically.
+//
+// {id} is "<channel>:<lang>", where channel is one of "email_subject",
+// "email_body" or "telegram", e.g. /api/v1/admin/templates/acme/email_body:en.
+func HandleTemplatePreview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serviceName := vars["service"]
+	id := vars["id"]
+
+	service, ok := config.GetService(serviceName)
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Service not configured")
+		return
+	}
+
+	channel, lang, found := strings.Cut(id, ":")
+	if !found {
+		respondWithError(w, http.StatusBadRequest, "id must be <channel>:<lang>")
+		return
+	}
+
+	var path string
+	switch channel {
+	case "email_subject":
+		path = service.EmailTemplateSubjectPaths[lang]
+	case "email_body":
+		path = service.EmailTemplateBodyPaths[lang]
+	case "telegram":
+		path = service.TelegramTemplatePaths[lang]
+	default:
+		respondWithError(w, http.StatusBadRequest, "Unknown channel: "+channel)
+		return
+	}
+
+	// A path on disk takes priority; otherwise fall back to whatever
+	// LoadServices already resolved (env body, override dir, or embedded
+	// default) so previewing matches what would actually be sent.
+	var rawText string
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to read template")
+			return
+		}
+		rawText = string(raw)
+	} else {
+		switch channel {
+		case "email_subject":
+			rawText = service.EmailTemplates[lang].Subject
+		case "email_body":
+			rawText = service.EmailTemplates[lang].Body
+		case "telegram":
+			rawText = service.TelegramTemplates[lang]
+		}
+	}
+	if rawText == "" {
+		respondWithError(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	sample := map[string]interface{}{
+		"full_name":    "Jane Doe",
+		"company":      "Acme Inc.",
+		"contact":      "jane@example.com",
+		"project_link": "https://example.com/project",
+		"payment":      "card",
+		"start_date":   "2026-01-01",
+		"language":     lang,
+		"service_name": service.Name,
+	}
+
+	var rendered string
+	var renderedVars []string
+	var err error
+	if channel == "email_body" && strings.HasPrefix(strings.TrimSpace(rawText), "<") {
+		rendered, renderedVars, err = Renderer.RenderHTML(channel, rawText, lang, sample)
+	} else {
+		rendered, renderedVars, err = Renderer.Render(channel, rawText, lang, sample)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Template render error: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"service":   serviceName,
+		"channel":   channel,
+		"lang":      lang,
+		"rendered":  rendered,
+		"variables": renderedVars,
+	})
+}
+
+// HandleTemplateCatalog lists every "<channel>:<lang>" identifier that ships
+// as a builtin default, so a frontend can discover what's available without
+// needing a configured service.
+func HandleTemplateCatalog(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"builtin": templates.List(),
+	})
+}
@@ -0,0 +1,242 @@
+// Package idempotency rejects duplicate order submissions arriving within
+// a configurable window. A rolling pair of bloom filters gives a cheap
+// probabilistic first pass; a positive hit is always confirmed (or
+// refuted) against an exact SQLite lookup before being treated as a
+// duplicate, so false positives never surface to the caller.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bits-and-blooms/bloom/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// filterEstimatedItems/filterFalsePositiveRate size each rolling filter.
+	filterEstimatedItems    = 100_000
+	filterFalsePositiveRate = 0.001
+	filterRotationInterval  = time.Hour
+)
+
+// Duplicate describes a previously-seen submission.
+type Duplicate struct {
+	FirstSeen time.Time
+	Count     int
+}
+
+// Store is the idempotency layer for order submissions. Fingerprint
+// computes the dedup key for an order; Check reports whether it (or an
+// Idempotency-Key) has been seen before; Record persists a new entry.
+type Store struct {
+	db          *sql.DB
+	logger      *zap.Logger
+	persistPath string
+
+	mu        sync.Mutex
+	current   *bloom.BloomFilter
+	previous  *bloom.BloomFilter
+	rotatedAt time.Time
+
+	stop chan struct{}
+}
+
+// NewStore builds a Store, ensures its exact-match table exists, and loads
+// any persisted filter state from persistPath (a missing file just starts
+// fresh — it isn't an error).
+func NewStore(db *sql.DB, logger *zap.Logger, persistPath string) (*Store, error) {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS order_fingerprints (
+			fingerprint     TEXT PRIMARY KEY,
+			idempotency_key TEXT,
+			first_seen      DATETIME NOT NULL,
+			count           INTEGER NOT NULL DEFAULT 1
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create order_fingerprints table: %w", err)
+	}
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_order_fingerprints_idempotency_key
+		ON order_fingerprints(idempotency_key)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency_key index: %w", err)
+	}
+
+	s := &Store{
+		db:          db,
+		logger:      logger,
+		persistPath: persistPath,
+		stop:        make(chan struct{}),
+	}
+
+	if err := s.load(); err != nil {
+		if s.logger != nil {
+			s.logger.Warn("failed to load persisted idempotency filters, starting fresh", zap.Error(err))
+		}
+		s.current = newFilter()
+		s.previous = newFilter()
+		s.rotatedAt = time.Now()
+	}
+
+	return s, nil
+}
+
+func newFilter() *bloom.BloomFilter {
+	return bloom.NewWithEstimates(filterEstimatedItems, filterFalsePositiveRate)
+}
+
+// Fingerprint computes the dedup key for an order: SHA-256 of
+// service|full_name|contact|payment|sha256(specPDF)|sha256(contractPDF).
+func Fingerprint(serviceName, fullName, contactInfo, paymentMethod string, specificationPDF, contractPDF []byte) string {
+	specHash := sha256.Sum256(specificationPDF)
+	contractHash := sha256.Sum256(contractPDF)
+
+	input := strings.Join([]string{
+		serviceName,
+		fullName,
+		contactInfo,
+		paymentMethod,
+		hex.EncodeToString(specHash[:]),
+		hex.EncodeToString(contractHash[:]),
+	}, "|")
+
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+}
+
+// Check reports whether fingerprint (or idempotencyKey, if supplied) has
+// already been recorded. A bloom-filter miss always means "new"; a hit is
+// confirmed against the exact table before being trusted.
+func (s *Store) Check(fingerprint, idempotencyKey string) (*Duplicate, error) {
+	s.maybeRotate()
+
+	if idempotencyKey != "" {
+		if dup, err := s.lookupByIdempotencyKey(idempotencyKey); err != nil {
+			return nil, err
+		} else if dup != nil {
+			return dup, nil
+		}
+	}
+
+	s.mu.Lock()
+	probablySeen := s.current.TestString(fingerprint) || s.previous.TestString(fingerprint)
+	s.mu.Unlock()
+
+	if !probablySeen {
+		return nil, nil
+	}
+
+	return s.lookupByFingerprint(fingerprint)
+}
+
+// Record inserts fingerprint into the rolling filter and the exact table
+// (bumping the hit count if it's already present — Check should have
+// already rejected a true duplicate, so this mainly guards races).
+func (s *Store) Record(fingerprint, idempotencyKey string) error {
+	s.mu.Lock()
+	s.current.AddString(fingerprint)
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`
+		INSERT INTO order_fingerprints (fingerprint, idempotency_key, first_seen, count)
+		VALUES (?, ?, ?, 1)
+		ON CONFLICT(fingerprint) DO UPDATE SET count = count + 1
+	`, fingerprint, nullableString(idempotencyKey), now)
+	if err != nil {
+		return fmt.Errorf("failed to record order fingerprint: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) lookupByFingerprint(fingerprint string) (*Duplicate, error) {
+	var firstSeen time.Time
+	var count int
+	err := s.db.QueryRow(
+		"SELECT first_seen, count FROM order_fingerprints WHERE fingerprint = ?", fingerprint,
+	).Scan(&firstSeen, &count)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up order fingerprint: %w", err)
+	}
+	return &Duplicate{FirstSeen: firstSeen, Count: count}, nil
+}
+
+func (s *Store) lookupByIdempotencyKey(key string) (*Duplicate, error) {
+	var firstSeen time.Time
+	var count int
+	err := s.db.QueryRow(
+		"SELECT first_seen, count FROM order_fingerprints WHERE idempotency_key = ?", key,
+	).Scan(&firstSeen, &count)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+	return &Duplicate{FirstSeen: firstSeen, Count: count}, nil
+}
+
+// maybeRotate swaps current into previous and starts a fresh current once
+// filterRotationInterval has elapsed, giving an effective TTL of one to
+// two rotation intervals without unbounded filter growth.
+func (s *Store) maybeRotate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.rotatedAt) < filterRotationInterval {
+		return
+	}
+	s.previous = s.current
+	s.current = newFilter()
+	s.rotatedAt = time.Now()
+}
+
+// StartRotationTicker runs a background goroutine that rotates the filters
+// on schedule even when no requests arrive to trigger maybeRotate lazily.
+func (s *Store) StartRotationTicker() {
+	go func() {
+		ticker := time.NewTicker(filterRotationInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.maybeRotate()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the rotation ticker and persists filter state to disk so a
+// restart doesn't lose dedup memory.
+func (s *Store) Close() error {
+	close(s.stop)
+	return s.persist()
+}
+
+func nullableString(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+// ensure the persist file's parent directory exists before first write.
+func ensureDir(path string) error {
+	dir := path[:strings.LastIndex(path, "/")]
+	if dir == "" {
+		return nil
+	}
+	return os.MkdirAll(dir, 0755)
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+// persistedState is the on-disk snapshot of the rolling filter pair,
+// gob-encoded since bloom.BloomFilter implements GobEncode/GobDecode.
+type persistedState struct {
+	RotatedAt time.Time
+	Previous  *bloom.BloomFilter
+	Current   *bloom.BloomFilter
+}
+
+// persist writes the current filter pair to s.persistPath so a restart
+// doesn't lose dedup memory. A blank persistPath disables persistence.
+func (s *Store) persist() error {
+	if s.persistPath == "" {
+		return nil
+	}
+	if err := ensureDir(s.persistPath); err != nil {
+		return fmt.Errorf("failed to create idempotency persist dir: %w", err)
+	}
+
+	s.mu.Lock()
+	state := persistedState{
+		RotatedAt: s.rotatedAt,
+		Previous:  s.previous,
+		Current:   s.current,
+	}
+	s.mu.Unlock()
+
+	f, err := os.Create(s.persistPath)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency persist file: %w", err)
+	}
+	defer f.Close()
+
+	if err := gob.NewEncoder(f).Encode(state); err != nil {
+		return fmt.Errorf("failed to encode idempotency filters: %w", err)
+	}
+	return nil
+}
+
+// load reads a previously-persisted filter pair from s.persistPath. A
+// missing file (or blank persistPath) is reported as an error so the
+// caller can fall back to fresh filters without treating it as fatal.
+func (s *Store) load() error {
+	if s.persistPath == "" {
+		return fmt.Errorf("idempotency: no persist path configured")
+	}
+
+	f, err := os.Open(s.persistPath)
+	if err != nil {
+		return fmt.Errorf("failed to open idempotency persist file: %w", err)
+	}
+	defer f.Close()
+
+	var state persistedState
+	if err := gob.NewDecoder(f).Decode(&state); err != nil {
+		return fmt.Errorf("failed to decode idempotency filters: %w", err)
+	}
+
+	s.mu.Lock()
+	s.rotatedAt = state.RotatedAt
+	s.previous = state.Previous
+	s.current = state.Current
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+
+	"axcommutator/app/config"
+	"axcommutator/app/utils"
+)
+
+// ErrPushNotSupported is returned by backends that only accept addressed
+// Message sends (e.g. email has no concept of a template-resolved push).
+var ErrPushNotSupported = errors.New("messenger: push not supported by this backend")
+
+func init() {
+	RegisterFactory("email", func(service config.ServiceConfig) Messenger {
+		return EmailMessenger{Service: service}
+	})
+}
+
+// EmailMessenger adapts utils.SendOrderEmail to the Messenger interface.
+type EmailMessenger struct {
+	Service config.ServiceConfig
+}
+
+// Name identifies this backend in ServiceConfig.Messengers and Manager.Dispatch.
+func (e EmailMessenger) Name() string {
+	return "email"
+}
+
+// Send builds email attachments from msg.Attachments and sends the message
+// through the service's configured SMTP settings.
+func (e EmailMessenger) Send(ctx context.Context, msg Message) error {
+	attachments := make([]utils.EmailAttachment, 0, len(msg.Attachments))
+	for _, a := range msg.Attachments {
+		attachments = append(attachments, utils.EmailAttachment{
+			Name:      a.Name,
+			Content:   a.Content,
+			MIME:      a.MIME,
+			ContentID: a.ContentID,
+		})
+	}
+	return utils.SendOrderEmail(ctx, e.Service, msg.Subject, msg.Body, msg.HTML, msg.Recipient, attachments)
+}
+
+// Push is not meaningful for email; email always has an explicit subject/body.
+func (e EmailMessenger) Push(ctx context.Context, note Notification) error {
+	return ErrPushNotSupported
+}
+
+// UsesPush is false: email is always dispatched as an addressed Send.
+func (e EmailMessenger) UsesPush() bool {
+	return false
+}
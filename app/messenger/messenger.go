@@ -0,0 +1,145 @@
+// Package messenger defines the pluggable notification-backend contract
+// (email, Telegram, and anything registered alongside them) and a manager
+// that dispatches a single order/event across whichever channels a service
+// is configured to use.
+package messenger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Message is an explicit, addressed piece of content (an email-like send):
+// it has a recipient, a subject and a body, plus optional attachments.
+// HTML is optional; backends that can't render it (or don't need it) are
+// free to ignore it and fall back to Body.
+type Message struct {
+	Recipient   string
+	Subject     string
+	Body        string
+	HTML        string
+	Data        map[string]interface{}
+	Attachments []Attachment
+}
+
+// Attachment is a named blob of content to be carried along with a Message.
+// ContentID marks it as an inline image referenced as "cid:<ContentID>"
+// from Message.HTML rather than a regular file attachment.
+type Attachment struct {
+	Name      string
+	Content   []byte
+	MIME      string
+	ContentID string
+}
+
+// Notification is a templated, push-style event (no explicit subject/body) —
+// the backend resolves the right localized template itself, e.g. Telegram.
+type Notification struct {
+	Lang string
+	Data map[string]interface{}
+	// ChatID is a resolved Telegram recipient (from the bot's verified
+	// recipient registry); empty falls back to the service's static
+	// ServiceConfig.Telegram.ChatID.
+	ChatID string
+}
+
+// Messenger is implemented by every notification backend. Send handles
+// addressed messages (email); Push handles templated backend-resolved
+// notifications (Telegram and similar chat backends). UsesPush reports
+// which of the two a given backend expects, so Dispatch can route by the
+// backend's own contract instead of guessing from the event's shape.
+type Messenger interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+	Push(ctx context.Context, note Notification) error
+	UsesPush() bool
+}
+
+// Manager owns the set of registered Messenger backends and dispatches to
+// them by name, the way listmonk's App composes its messenger backends.
+type Manager struct {
+	mu         sync.RWMutex
+	messengers map[string]Messenger
+	logger     *zap.Logger
+}
+
+// NewManager returns an empty Manager ready for Register calls.
+func NewManager(logger *zap.Logger) *Manager {
+	return &Manager{
+		messengers: make(map[string]Messenger),
+		logger:     logger,
+	}
+}
+
+// Register adds msgr to the registry under its own Name(), overwriting
+// any previous messenger registered under the same name. Third-party
+// backends (Slack, Discord, Matrix, webhooks, SMS, ...) register here
+// from main.go the same way the built-in email/Telegram backends do.
+func (m *Manager) Register(msgr Messenger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messengers[msgr.Name()] = msgr
+}
+
+// Get returns the messenger registered under name, if any.
+func (m *Manager) Get(name string) (Messenger, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	msgr, ok := m.messengers[name]
+	return msgr, ok
+}
+
+// Names returns every registered messenger name, for surfacing to the
+// frontend (e.g. from a config-script endpoint).
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.messengers))
+	for name := range m.messengers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// DispatchResult is the per-channel outcome of a Dispatch call.
+type DispatchResult struct {
+	Channel string
+	Err     error
+}
+
+// Dispatch sends msg/note through each named channel. Each messenger's own
+// UsesPush tells Dispatch whether it expects an addressed Send or a
+// templated Push — the choice is never inferred from msg's contents, since
+// every channel in a fan-out shares the same msg/note pair. Unknown channel
+// names are reported as errors rather than silently skipped.
+func (m *Manager) Dispatch(ctx context.Context, channels []string, msg Message, note Notification) []DispatchResult {
+	results := make([]DispatchResult, 0, len(channels))
+
+	for _, name := range channels {
+		msgr, ok := m.Get(name)
+		if !ok {
+			err := fmt.Errorf("messenger %q is not registered", name)
+			results = append(results, DispatchResult{Channel: name, Err: err})
+			continue
+		}
+
+		var err error
+		if msgr.UsesPush() {
+			err = msgr.Push(ctx, note)
+		} else {
+			err = msgr.Send(ctx, msg)
+		}
+
+		if err != nil && m.logger != nil {
+			m.logger.Error("messenger dispatch failed",
+				zap.String("channel", name),
+				zap.Error(err))
+		}
+		results = append(results, DispatchResult{Channel: name, Err: err})
+	}
+
+	return results
+}
@@ -0,0 +1,58 @@
+package messenger
+
+import (
+	"sync"
+
+	"axcommutator/app/config"
+	"go.uber.org/zap"
+)
+
+// Factory builds a Messenger bound to a specific service's credentials
+// (SMTP host/port, Telegram bot token, a webhook URL, ...). Backends are
+// registered by name so a ServiceConfig.Messengers list can select which
+// ones to instantiate.
+type Factory func(service config.ServiceConfig) Messenger
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// RegisterFactory adds a named backend to the global registry. Built-in
+// backends register themselves from init(); third-party backends (Slack,
+// Discord, Matrix, generic webhooks, SMS, ...) register the same way from
+// main.go before the server starts accepting requests.
+func RegisterFactory(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = f
+}
+
+// RegisteredNames returns every backend name currently registered, for
+// surfacing to the frontend (e.g. from a config-script endpoint).
+func RegisteredNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// BuildManager instantiates a Manager carrying one Messenger per name in
+// service.Messengers that has a registered Factory. Names without a
+// matching factory are skipped; Dispatch will report them as errors
+// instead of silently dropping the channel.
+func BuildManager(service config.ServiceConfig, logger *zap.Logger) *Manager {
+	m := NewManager(logger)
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, name := range service.Messengers {
+		if factory, ok := registry[name]; ok {
+			m.Register(factory(service))
+		}
+	}
+	return m
+}
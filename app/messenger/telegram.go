@@ -0,0 +1,48 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+
+	"axcommutator/app/config"
+	"axcommutator/app/utils"
+)
+
+// ErrSendNotSupported is returned by push-only backends (e.g. Telegram)
+// when asked for an addressed Message send.
+var ErrSendNotSupported = errors.New("messenger: send not supported by this backend")
+
+func init() {
+	RegisterFactory("telegram", func(service config.ServiceConfig) Messenger {
+		return TelegramMessenger{Service: service}
+	})
+}
+
+// TelegramMessenger adapts utils.SendTelegramNotification to the Messenger interface.
+type TelegramMessenger struct {
+	Service config.ServiceConfig
+}
+
+// Name identifies this backend in ServiceConfig.Messengers and Manager.Dispatch.
+func (t TelegramMessenger) Name() string {
+	return "telegram"
+}
+
+// Send is not meaningful for Telegram; Telegram always resolves its own
+// localized template via Push.
+func (t TelegramMessenger) Send(ctx context.Context, msg Message) error {
+	return ErrSendNotSupported
+}
+
+// Push renders the localized Telegram template for note.Lang and sends it
+// to note.ChatID (a resolved recipient), falling back to the service's
+// static Telegram.ChatID when no recipient has been resolved yet.
+func (t TelegramMessenger) Push(ctx context.Context, note Notification) error {
+	return utils.SendTelegramNotification(ctx, t.Service, note.ChatID, note.Lang, note.Data)
+}
+
+// UsesPush is true: Telegram always resolves its own localized template
+// from note, never an addressed Message.
+func (t TelegramMessenger) UsesPush() bool {
+	return true
+}
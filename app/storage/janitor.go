@@ -0,0 +1,282 @@
+// Package storage persists metadata for temporary uploads in an embedded
+// key-value store, so a scheduled deletion survives a restart instead of
+// living only in a goroutine that sleeps until its file expires.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+var filesBucket = []byte("files")
+
+// ErrRecordNotFound is returned by Get/Touch when no record exists for a
+// given UUID.
+var ErrRecordNotFound = errors.New("file record not found")
+
+// FileRecord is the persisted metadata for one managed file.
+type FileRecord struct {
+	UUID      string    `json:"uuid"`
+	Path      string    `json:"path"`
+	Sha256    string    `json:"sha256"`
+	MimeType  string    `json:"mime_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Stats summarizes the janitor's bucket for /metrics-style reporting.
+type Stats struct {
+	Live    int
+	Expired int
+}
+
+// Janitor persists FileRecords in an embedded bbolt database and reclaims
+// expired files on a single ticker loop instead of one goroutine per file.
+type Janitor struct {
+	db      *bbolt.DB
+	logger  *zap.Logger
+	scanDir string
+	stop    chan struct{}
+}
+
+// NewJanitor opens (or creates) the bbolt database at dbPath and
+// reconciles its records against scanDir's contents: records whose file
+// is gone are dropped, and files with no matching record are removed as
+// orphans. scanDir is the directory that hosts every file the janitor
+// will ever be asked to track.
+func NewJanitor(dbPath, scanDir string, logger *zap.Logger) (*Janitor, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create janitor db directory: %w", err)
+	}
+
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open janitor db: %w", err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(filesBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create files bucket: %w", err)
+	}
+
+	j := &Janitor{
+		db:      db,
+		logger:  logger,
+		scanDir: scanDir,
+		stop:    make(chan struct{}),
+	}
+
+	if err := j.reconcile(); err != nil && logger != nil {
+		logger.Warn("janitor reconciliation failed", zap.Error(err))
+	}
+
+	return j, nil
+}
+
+// reconcile drops records whose file is gone and removes files on disk
+// with no matching record, so a crash between a file write and its record
+// (or vice versa) can't leak either one forever.
+func (j *Janitor) reconcile() error {
+	known := make(map[string]bool)
+	var orphanKeys [][]byte
+
+	if err := j.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(filesBucket).ForEach(func(k, v []byte) error {
+			var rec FileRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				orphanKeys = append(orphanKeys, append([]byte(nil), k...))
+				return nil
+			}
+			if _, err := os.Stat(rec.Path); err != nil {
+				orphanKeys = append(orphanKeys, append([]byte(nil), k...))
+				return nil
+			}
+			known[filepath.Clean(rec.Path)] = true
+			return nil
+		})
+	}); err != nil {
+		return fmt.Errorf("failed to scan janitor bucket: %w", err)
+	}
+
+	if len(orphanKeys) > 0 {
+		if err := j.db.Update(func(tx *bbolt.Tx) error {
+			b := tx.Bucket(filesBucket)
+			for _, key := range orphanKeys {
+				if err := b.Delete(key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to drop orphan records: %w", err)
+		}
+	}
+
+	entries, err := os.ReadDir(j.scanDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to scan %s: %w", j.scanDir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Clean(filepath.Join(j.scanDir, entry.Name()))
+		if !known[path] {
+			if err := os.Remove(path); err != nil && j.logger != nil {
+				j.logger.Warn("failed to remove orphan upload", zap.String("path", path), zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// Put persists a record, re-arming its expiration.
+func (j *Janitor) Put(rec FileRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal file record: %w", err)
+	}
+	return j.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(filesBucket).Put([]byte(rec.UUID), data)
+	})
+}
+
+// Get returns a previously persisted record.
+func (j *Janitor) Get(uuid string) (FileRecord, error) {
+	var rec FileRecord
+	found := false
+	err := j.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(filesBucket).Get([]byte(uuid))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &rec)
+	})
+	if err != nil {
+		return FileRecord{}, err
+	}
+	if !found {
+		return FileRecord{}, ErrRecordNotFound
+	}
+	return rec, nil
+}
+
+// Delete removes both the file and its record. If the file is already
+// gone that's not an error, but any other filesystem failure leaves the
+// record in place so a later sweep can retry.
+func (j *Janitor) Delete(uuid string) error {
+	rec, err := j.Get(uuid)
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(rec.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+
+	return j.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(filesBucket).Delete([]byte(uuid))
+	})
+}
+
+// Touch extends a record's expiration, e.g. when a file is re-requested
+// and should outlive its original 24h window.
+func (j *Janitor) Touch(uuid string, newExpiry time.Time) error {
+	rec, err := j.Get(uuid)
+	if err != nil {
+		return err
+	}
+	rec.ExpiresAt = newExpiry
+	return j.Put(rec)
+}
+
+// Stats reports how many tracked files are still live versus past their
+// expiry but not yet swept, for /metrics-style reporting.
+func (j *Janitor) Stats() (Stats, error) {
+	var stats Stats
+	now := time.Now()
+	err := j.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(filesBucket).ForEach(func(k, v []byte) error {
+			var rec FileRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return nil
+			}
+			if rec.ExpiresAt.After(now) {
+				stats.Live++
+			} else {
+				stats.Expired++
+			}
+			return nil
+		})
+	})
+	return stats, err
+}
+
+// StartSweeper runs a background goroutine that deletes expired files at
+// the given interval, until Close is called.
+func (j *Janitor) StartSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.sweep()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (j *Janitor) sweep() {
+	now := time.Now()
+	var expired []string
+	if err := j.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(filesBucket).ForEach(func(k, v []byte) error {
+			var rec FileRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return nil
+			}
+			if !rec.ExpiresAt.After(now) {
+				expired = append(expired, rec.UUID)
+			}
+			return nil
+		})
+	}); err != nil {
+		if j.logger != nil {
+			j.logger.Warn("janitor sweep scan failed", zap.Error(err))
+		}
+		return
+	}
+
+	for _, uuid := range expired {
+		if err := j.Delete(uuid); err != nil && j.logger != nil {
+			j.logger.Warn("failed to delete expired file", zap.String("uuid", uuid), zap.Error(err))
+		}
+	}
+}
+
+// Close stops the sweeper goroutine started by StartSweeper and closes the
+// underlying database.
+func (j *Janitor) Close() error {
+	close(j.stop)
+	return j.db.Close()
+}
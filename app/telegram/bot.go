@@ -0,0 +1,276 @@
+// Package telegram implements the two-way side of Telegram notifications:
+// a long-polling/webhook bot that tracks which app users have messaged it,
+// resolves their chat ID and language, and verifies them via short-lived
+// PINs so utils.SendTelegramNotification can target a real recipient
+// instead of one static, service-wide ChatID.
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	pollInterval  = 1 * time.Second
+	pinCharset    = "0123456789"
+	pinLength     = 6
+	pinSweepEvery = time.Minute
+
+	// verifiedRetention is how long a verified PIN stays readable via
+	// Verified after the user confirms it, so a frontend poll that lands
+	// just after verification still sees the result. Past that it's swept
+	// like any expired entry, so b.pins doesn't grow for the life of the
+	// process.
+	verifiedRetention = 5 * time.Minute
+)
+
+type pinEntry struct {
+	userID     string
+	expiresAt  time.Time
+	chatID     int64
+	verified   bool
+	verifiedAt time.Time
+}
+
+// Bot runs the inbound side of a Telegram bot: it consumes updates (via
+// long-polling or a webhook), turns "/lang" and PIN messages into
+// recipient-store writes, and answers "/start" with instructions.
+type Bot struct {
+	token  string
+	http   httpDoer
+	store  RecipientStore
+	logger *zap.Logger
+
+	offset int64
+
+	mu   sync.Mutex
+	pins map[string]*pinEntry
+
+	stop chan struct{}
+}
+
+// NewBot builds a Bot using the default *http.Client. store persists the
+// user-id -> chat-id/language mapping the bot builds up as users verify.
+func NewBot(token string, store RecipientStore, logger *zap.Logger) *Bot {
+	return &Bot{
+		token:  token,
+		http:   &http.Client{Timeout: apiTimeout},
+		store:  store,
+		logger: logger,
+		pins:   make(map[string]*pinEntry),
+		stop:   make(chan struct{}),
+	}
+}
+
+// GenerateVerificationPIN creates a short-lived numeric PIN for userID. The
+// caller (typically an HTTP handler) shows it to the user, who then sends
+// it to the bot as a Telegram message to complete verification.
+func (b *Bot) GenerateVerificationPIN(userID string, ttl time.Duration) (string, error) {
+	pin, err := randomPIN()
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	b.pins[pin] = &pinEntry{userID: userID, expiresAt: time.Now().Add(ttl)}
+	b.mu.Unlock()
+
+	return pin, nil
+}
+
+// Verified reports whether pin has been consumed by a Telegram user yet,
+// and if so the chat ID it can now be reached on.
+func (b *Bot) Verified(pin string) (int64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.pins[pin]
+	if !ok || !entry.verified {
+		return 0, false
+	}
+	return entry.chatID, true
+}
+
+// Recipient looks up the chat ID a verified app user can be reached on, so
+// callers sending a notification can target them directly instead of the
+// service's static, shared chat ID.
+func (b *Bot) Recipient(userID string) (Recipient, bool) {
+	return b.store.Get(userID)
+}
+
+func randomPIN() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < pinLength; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(pinCharset))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate PIN: %w", err)
+		}
+		sb.WriteByte(pinCharset[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// Start runs the long-polling loop until ctx is canceled or Stop is called.
+func (b *Bot) Start(ctx context.Context) {
+	go b.sweepExpiredPINs(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.stop:
+			return
+		default:
+		}
+
+		updates, err := b.getUpdates(b.offset)
+		if err != nil {
+			if b.logger != nil {
+				b.logger.Warn("telegram getUpdates failed", zap.Error(err))
+			}
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		for _, u := range updates {
+			b.offset = u.UpdateID + 1
+			b.handleUpdate(u)
+		}
+	}
+}
+
+// Stop ends the long-polling loop started by Start.
+func (b *Bot) Stop() {
+	close(b.stop)
+}
+
+// HandleWebhook implements http.HandlerFunc for bots configured in webhook
+// mode instead of long-polling: Telegram POSTs one Update per call.
+func (b *Bot) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	var u update
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		http.Error(w, "invalid update payload", http.StatusBadRequest)
+		return
+	}
+	b.handleUpdate(u)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (b *Bot) handleUpdate(u update) {
+	if u.Message == nil {
+		return
+	}
+	msg := u.Message
+	text := strings.TrimSpace(msg.Text)
+	chatID := msg.Chat.ID
+	lang := msg.From.LanguageCode
+	username := msg.From.Username
+
+	switch {
+	case text == "/start":
+		b.handleStart(chatID, lang)
+	case strings.HasPrefix(text, "/lang"):
+		b.handleLang(chatID, text)
+	default:
+		b.tryVerifyPIN(text, chatID, lang, username)
+	}
+}
+
+func (b *Bot) handleStart(chatID int64, lang string) {
+	if err := b.sendMessage(chatID, "Send the verification PIN shown on the site to link your account."); err != nil && b.logger != nil {
+		b.logger.Warn("failed to send /start reply", zap.Error(err))
+	}
+}
+
+func (b *Bot) handleLang(chatID int64, text string) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		_ = b.sendMessage(chatID, "Usage: /lang <code>, e.g. /lang en")
+		return
+	}
+	lang := strings.ToLower(fields[1])
+
+	r, ok := b.store.FindByChatID(chatID)
+	if !ok {
+		_ = b.sendMessage(chatID, "Verify your account first by sending your PIN.")
+		return
+	}
+	r.Lang = lang
+	r.UpdatedAt = time.Now().UTC()
+	if err := b.store.Put(r); err != nil && b.logger != nil {
+		b.logger.Warn("failed to persist language change", zap.Error(err))
+		return
+	}
+	_ = b.sendMessage(chatID, fmt.Sprintf("Language set to %s", lang))
+}
+
+// tryVerifyPIN checks whether text matches a pending PIN; if so it records
+// the sender as that PIN's recipient, marks it consumed, and persists a
+// Recipient so future notifications can resolve this chat ID by user ID.
+func (b *Bot) tryVerifyPIN(text string, chatID int64, lang, username string) {
+	b.mu.Lock()
+	entry, ok := b.pins[text]
+	if !ok || entry.verified || time.Now().After(entry.expiresAt) {
+		b.mu.Unlock()
+		return
+	}
+	entry.verified = true
+	entry.verifiedAt = time.Now()
+	entry.chatID = chatID
+	userID := entry.userID
+	b.mu.Unlock()
+
+	err := b.store.Put(Recipient{
+		UserID:    userID,
+		ChatID:    chatID,
+		Username:  username,
+		Lang:      lang,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil && b.logger != nil {
+		b.logger.Error("failed to persist verified recipient", zap.Error(err), zap.String("user_id", userID))
+	}
+
+	_ = b.sendMessage(chatID, "Verified! You'll now receive notifications here.")
+}
+
+func (b *Bot) sweepExpiredPINs(ctx context.Context) {
+	ticker := time.NewTicker(pinSweepEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.sweepPinsOnce()
+		}
+	}
+}
+
+// sweepPinsOnce deletes every pin entry that has either expired unverified
+// or sat verified past verifiedRetention, the same pass sweepExpiredPINs
+// runs on each tick. Split out so tests can drive it directly instead of
+// waiting on (or duplicating) the ticker loop.
+func (b *Bot) sweepPinsOnce() {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for pin, entry := range b.pins {
+		expired := !entry.verified && now.After(entry.expiresAt)
+		stale := entry.verified && now.Sub(entry.verifiedAt) > verifiedRetention
+		if expired || stale {
+			delete(b.pins, pin)
+		}
+	}
+}
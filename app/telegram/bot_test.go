@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+// memStore is a minimal in-memory RecipientStore for tests, in lieu of the
+// file-backed jsonFileStore.
+type memStore struct {
+	byID map[string]Recipient
+}
+
+func newMemStore() *memStore {
+	return &memStore{byID: make(map[string]Recipient)}
+}
+
+func (s *memStore) Get(userID string) (Recipient, bool) {
+	r, ok := s.byID[userID]
+	return r, ok
+}
+
+func (s *memStore) FindByChatID(chatID int64) (Recipient, bool) {
+	for _, r := range s.byID {
+		if r.ChatID == chatID {
+			return r, true
+		}
+	}
+	return Recipient{}, false
+}
+
+func (s *memStore) Put(r Recipient) error {
+	s.byID[r.UserID] = r
+	return nil
+}
+
+func newTestBot() *Bot {
+	return &Bot{
+		http:  &stubDoer{resp: jsonResponse(`{"ok": true, "result": {}}`)},
+		store: newMemStore(),
+		pins:  make(map[string]*pinEntry),
+		stop:  make(chan struct{}),
+	}
+}
+
+func TestGenerateAndVerifyPIN(t *testing.T) {
+	b := newTestBot()
+
+	pin, err := b.GenerateVerificationPIN("user-1", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateVerificationPIN returned error: %v", err)
+	}
+	if len(pin) != pinLength {
+		t.Fatalf("expected a %d-digit PIN, got %q", pinLength, pin)
+	}
+
+	if _, ok := b.Verified(pin); ok {
+		t.Fatal("expected pin to be unverified before any message arrives")
+	}
+
+	b.tryVerifyPIN(pin, 42, "en", "alice")
+
+	chatID, ok := b.Verified(pin)
+	if !ok {
+		t.Fatal("expected pin to be verified after tryVerifyPIN")
+	}
+	if chatID != 42 {
+		t.Errorf("expected chat id 42, got %d", chatID)
+	}
+
+	r, ok := b.store.Get("user-1")
+	if !ok {
+		t.Fatal("expected a recipient to be persisted after verification")
+	}
+	if r.ChatID != 42 || r.Username != "alice" {
+		t.Errorf("unexpected recipient: %+v", r)
+	}
+}
+
+func TestTryVerifyPINRejectsUnknownOrExpired(t *testing.T) {
+	b := newTestBot()
+
+	b.tryVerifyPIN("000000", 1, "en", "bob")
+	if _, ok := b.Verified("000000"); ok {
+		t.Fatal("expected an unknown pin to never verify")
+	}
+
+	pin, err := b.GenerateVerificationPIN("user-2", -time.Second)
+	if err != nil {
+		t.Fatalf("GenerateVerificationPIN returned error: %v", err)
+	}
+	b.tryVerifyPIN(pin, 2, "en", "carol")
+	if _, ok := b.Verified(pin); ok {
+		t.Fatal("expected an already-expired pin to be rejected")
+	}
+}
+
+func TestSweepExpiredPINsRemovesUnverifiedAndStaleVerified(t *testing.T) {
+	b := newTestBot()
+
+	now := time.Now()
+	b.pins["expired"] = &pinEntry{userID: "u1", expiresAt: now.Add(-time.Second)}
+	b.pins["pending"] = &pinEntry{userID: "u2", expiresAt: now.Add(time.Hour)}
+	b.pins["stale-verified"] = &pinEntry{userID: "u3", verified: true, verifiedAt: now.Add(-2 * verifiedRetention)}
+	b.pins["fresh-verified"] = &pinEntry{userID: "u4", verified: true, verifiedAt: now}
+
+	b.sweepPinsOnce()
+
+	if _, ok := b.pins["expired"]; ok {
+		t.Error("expected expired unverified pin to be swept")
+	}
+	if _, ok := b.pins["stale-verified"]; ok {
+		t.Error("expected stale verified pin to be swept")
+	}
+	if _, ok := b.pins["pending"]; !ok {
+		t.Error("expected pending pin to survive the sweep")
+	}
+	if _, ok := b.pins["fresh-verified"]; !ok {
+		t.Error("expected recently-verified pin to survive the sweep")
+	}
+}
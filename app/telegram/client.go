@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// apiTimeout exceeds the 30s long-poll wait requested in getUpdates so a
+// slow-but-empty poll isn't mistaken for a failed request.
+const apiTimeout = 35 * time.Second
+
+// httpDoer is satisfied by *http.Client; tests substitute a stub.
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+func (b *Bot) apiURL(method string) string {
+	return fmt.Sprintf("https://api.telegram.org/bot%s/%s", b.token, method)
+}
+
+func (b *Bot) call(method string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", method, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.apiURL(method), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(raw, &apiResp); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", method, err)
+	}
+	if !apiResp.OK {
+		return fmt.Errorf("telegram API error on %s: %s", method, apiResp.Description)
+	}
+	if out != nil && len(apiResp.Result) > 0 {
+		if err := json.Unmarshal(apiResp.Result, out); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (b *Bot) getUpdates(offset int64) ([]update, error) {
+	var updates []update
+	err := b.call("getUpdates", map[string]interface{}{
+		"offset":  offset,
+		"timeout": 30,
+	}, &updates)
+	return updates, err
+}
+
+func (b *Bot) sendMessage(chatID int64, text string) error {
+	return b.call("sendMessage", map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}, nil)
+}
@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// stubDoer is a minimal httpDoer stub that hands back a canned response
+// (or error) and records the last request it saw, so tests can assert on
+// the method/body without standing up a real HTTP server.
+type stubDoer struct {
+	resp    *http.Response
+	err     error
+	lastReq *http.Request
+}
+
+func (s *stubDoer) Do(req *http.Request) (*http.Response, error) {
+	s.lastReq = req
+	return s.resp, s.err
+}
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestGetUpdates(t *testing.T) {
+	stub := &stubDoer{resp: jsonResponse(`{
+		"ok": true,
+		"result": [
+			{"update_id": 1, "message": {"message_id": 10, "from": {"id": 5, "username": "alice"}, "chat": {"id": 42}, "text": "/start"}}
+		]
+	}`)}
+	b := &Bot{token: "test-token", http: stub}
+
+	updates, err := b.getUpdates(0)
+	if err != nil {
+		t.Fatalf("getUpdates returned error: %v", err)
+	}
+	if len(updates) != 1 {
+		t.Fatalf("expected 1 update, got %d", len(updates))
+	}
+	if updates[0].UpdateID != 1 {
+		t.Errorf("expected update_id 1, got %d", updates[0].UpdateID)
+	}
+	if updates[0].Message.Chat.ID != 42 {
+		t.Errorf("expected chat id 42, got %d", updates[0].Message.Chat.ID)
+	}
+
+	if stub.lastReq.Method != http.MethodPost {
+		t.Errorf("expected POST, got %s", stub.lastReq.Method)
+	}
+	if !strings.HasSuffix(stub.lastReq.URL.String(), "/getUpdates") {
+		t.Errorf("expected getUpdates endpoint, got %s", stub.lastReq.URL.String())
+	}
+}
+
+func TestGetUpdatesAPIError(t *testing.T) {
+	stub := &stubDoer{resp: jsonResponse(`{"ok": false, "description": "Unauthorized"}`)}
+	b := &Bot{token: "bad-token", http: stub}
+
+	if _, err := b.getUpdates(0); err == nil {
+		t.Fatal("expected an error for a non-ok API response, got nil")
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	stub := &stubDoer{resp: jsonResponse(`{"ok": true, "result": {"message_id": 99}}`)}
+	b := &Bot{token: "test-token", http: stub}
+
+	if err := b.sendMessage(42, "hello"); err != nil {
+		t.Fatalf("sendMessage returned error: %v", err)
+	}
+
+	body, err := io.ReadAll(stub.lastReq.Body)
+	if err != nil {
+		t.Fatalf("failed to read request body: %v", err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("failed to parse request body: %v", err)
+	}
+	if got := fmt.Sprintf("%v", payload["chat_id"]); got != "42" {
+		t.Errorf("expected chat_id 42, got %v", payload["chat_id"])
+	}
+	if payload["text"] != "hello" {
+		t.Errorf("expected text %q, got %v", "hello", payload["text"])
+	}
+}
+
+func TestSendMessageTransportError(t *testing.T) {
+	stub := &stubDoer{err: fmt.Errorf("connection refused")}
+	b := &Bot{token: "test-token", http: stub}
+
+	if err := b.sendMessage(42, "hello"); err == nil {
+		t.Fatal("expected an error when the transport fails, got nil")
+	}
+}
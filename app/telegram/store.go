@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Recipient maps an app-user identifier to the Telegram chat the bot can
+// reach them on, plus the language it should use for that user.
+type Recipient struct {
+	UserID    string    `json:"user_id"`
+	ChatID    int64     `json:"chat_id"`
+	Username  string    `json:"username,omitempty"`
+	Lang      string    `json:"lang,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RecipientStore persists the user-id -> chat-id mapping the bot builds up
+// as users verify themselves. A JSON file is the default implementation;
+// swap in a BadgerDB/BoltDB-backed one by satisfying this interface if the
+// recipient count outgrows a flat file.
+type RecipientStore interface {
+	Get(userID string) (Recipient, bool)
+	FindByChatID(chatID int64) (Recipient, bool)
+	Put(r Recipient) error
+}
+
+// jsonFileStore is a RecipientStore backed by a single JSON file, rewritten
+// in full on every Put — simple and adequate for the bot's expected
+// recipient counts.
+type jsonFileStore struct {
+	path string
+	mu   sync.Mutex
+	byID map[string]Recipient
+}
+
+// NewJSONFileStore loads path if it exists, or starts empty if it doesn't.
+func NewJSONFileStore(path string) (RecipientStore, error) {
+	s := &jsonFileStore{path: path, byID: make(map[string]Recipient)}
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipient store %s: %w", path, err)
+	}
+	if len(raw) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(raw, &s.byID); err != nil {
+		return nil, fmt.Errorf("failed to parse recipient store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *jsonFileStore) Get(userID string) (Recipient, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.byID[userID]
+	return r, ok
+}
+
+func (s *jsonFileStore) FindByChatID(chatID int64) (Recipient, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.byID {
+		if r.ChatID == chatID {
+			return r, true
+		}
+	}
+	return Recipient{}, false
+}
+
+func (s *jsonFileStore) Put(r Recipient) error {
+	s.mu.Lock()
+	s.byID[r.UserID] = r
+	snapshot := make(map[string]Recipient, len(s.byID))
+	for k, v := range s.byID {
+		snapshot[k] = v
+	}
+	s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create recipient store directory: %w", err)
+	}
+
+	raw, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recipient store: %w", err)
+	}
+	if err := os.WriteFile(s.path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write recipient store %s: %w", s.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package telegram
+
+import "encoding/json"
+
+// The following mirror the small subset of the Telegram Bot API schema
+// (https://core.telegram.org/bots/api) this package needs; fields we
+// don't use are omitted rather than copied in wholesale.
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message"`
+}
+
+type message struct {
+	MessageID int64  `json:"message_id"`
+	From      user   `json:"from"`
+	Chat      chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type user struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
+}
+
+type chat struct {
+	ID int64 `json:"id"`
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
@@ -0,0 +1,98 @@
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed builtin/*
+var builtinFS embed.FS
+
+const builtinDir = "builtin"
+
+// Source identifies which layer a resolved template came from, for
+// startup logging.
+type Source string
+
+const (
+	SourceEnvBody     Source = "env_body"
+	SourceEnvPath     Source = "env_path"
+	SourceOverrideDir Source = "override_dir"
+	SourceEmbedded    Source = "embedded"
+	SourceNone        Source = "none"
+)
+
+func builtinFilename(kind, lang string) string {
+	return fmt.Sprintf("%s_%s.txt", kind, lang)
+}
+
+// Get returns the embedded default template for kind ("email_subject",
+// "email_body", "telegram") and lang, if one was shipped in builtin/.
+func Get(kind, lang string) (string, bool) {
+	raw, err := builtinFS.ReadFile(filepath.Join(builtinDir, builtinFilename(kind, lang)))
+	if err != nil {
+		return "", false
+	}
+	return string(raw), true
+}
+
+// List returns every "<kind>:<lang>" identifier shipped as a builtin
+// default, sorted, so handlers can report what's available out of the box.
+func List() []string {
+	entries, err := builtinFS.ReadDir(builtinDir)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".txt")
+		idx := strings.LastIndex(name, "_")
+		if idx < 0 {
+			continue
+		}
+		names = append(names, name[:idx]+":"+name[idx+1:])
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve picks a template's content using the order explicit env body ->
+// env path -> user override dir -> embedded default, returning whichever
+// layer it found content in so the caller can log it. overrideDir may be
+// blank, in which case that layer is skipped. A failure to read envPath is
+// reported through the returned error, but Resolve still falls through to
+// the remaining layers rather than failing the whole lookup — a missing or
+// unreadable path shouldn't ship a blank template when an override or the
+// embedded default could still serve one.
+func Resolve(kind, lang, envBody, envPath, overrideDir string) (string, Source, error) {
+	if envBody != "" {
+		return envBody, SourceEnvBody, nil
+	}
+
+	var readErr error
+	if envPath != "" {
+		content, err := os.ReadFile(envPath)
+		if err == nil {
+			return string(content), SourceEnvPath, nil
+		}
+		readErr = fmt.Errorf("failed to read template path %s: %w", envPath, err)
+	}
+
+	if overrideDir != "" {
+		path := filepath.Join(overrideDir, builtinFilename(kind, lang))
+		if content, err := os.ReadFile(path); err == nil {
+			return string(content), SourceOverrideDir, readErr
+		}
+	}
+
+	if content, ok := Get(kind, lang); ok {
+		return content, SourceEmbedded, readErr
+	}
+
+	return "", SourceNone, readErr
+}
@@ -0,0 +1,167 @@
+// Package templates renders notification bodies (email subject/body,
+// Telegram messages) with Go's text/template and html/template engines
+// instead of naive "{key}" string replacement, so templates can use
+// conditionals, loops, and a small set of helper funcs while staying
+// shared between every notification channel.
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Renderer parses and executes templates with a shared helper func set.
+type Renderer struct{}
+
+// NewRenderer returns a ready-to-use Renderer. It holds no state, so a
+// single instance can be shared across goroutines and channels.
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// Render executes tmplText as a text/template (plain-text bodies, Telegram
+// messages, email subjects) against data, with lang available to the `t`
+// helper. It returns the rendered text and the sorted list of variables
+// the template referenced, so a frontend can generate a form dynamically.
+func (r *Renderer) Render(name, tmplText, lang string, data map[string]interface{}) (string, []string, error) {
+	tmpl, err := texttemplate.New(name).Funcs(r.funcMap(lang)).Parse(tmplText)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse template %q: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", nil, fmt.Errorf("failed to render template %q: %w", name, err)
+	}
+
+	return buf.String(), collectVariables(tmpl.Tree), nil
+}
+
+// RenderHTML executes tmplText as an html/template (HTML email bodies),
+// which auto-escapes values for safe inclusion in markup.
+func (r *Renderer) RenderHTML(name, tmplText, lang string, data map[string]interface{}) (string, []string, error) {
+	tmpl, err := template.New(name).Funcs(r.funcMap(lang)).Parse(tmplText)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse template %q: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", nil, fmt.Errorf("failed to render template %q: %w", name, err)
+	}
+
+	return buf.String(), collectVariables(tmpl.Tree), nil
+}
+
+// funcMap returns the helper funcs available to every template. lang is
+// closed over so `t` can resolve nested per-language translations without
+// the template author having to pass it explicitly at every call site.
+func (r *Renderer) funcMap(lang string) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"default": func(fallback, value interface{}) interface{} {
+			if value == nil {
+				return fallback
+			}
+			if s, ok := value.(string); ok && s == "" {
+				return fallback
+			}
+			return value
+		},
+		"formatDate": func(layout string, value interface{}) (string, error) {
+			switch v := value.(type) {
+			case time.Time:
+				return v.Format(layout), nil
+			case string:
+				parsed, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					return "", fmt.Errorf("formatDate: %w", err)
+				}
+				return parsed.Format(layout), nil
+			default:
+				return fmt.Sprintf("%v", value), nil
+			}
+		},
+		// t looks up translations[lang][key], falling back to key itself
+		// when either the language or the key isn't present.
+		"t": func(translations interface{}, key string) string {
+			byLang, ok := translations.(map[string]interface{})
+			if !ok {
+				return key
+			}
+			localized, ok := byLang[lang].(map[string]interface{})
+			if !ok {
+				return key
+			}
+			if value, ok := localized[key].(string); ok {
+				return value
+			}
+			return key
+		},
+	}
+}
+
+// collectVariables walks a parsed template's syntax tree and returns the
+// sorted, deduplicated list of top-level field paths it references (e.g.
+// ".full_name", ".payment"), covering both plain actions and conditionals.
+func collectVariables(tree *parse.Tree) []string {
+	if tree == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch x := n.(type) {
+		case *parse.ListNode:
+			if x == nil {
+				return
+			}
+			for _, c := range x.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(x.Pipe)
+		case *parse.IfNode:
+			walk(x.Pipe)
+			walk(x.List)
+			walk(x.ElseList)
+		case *parse.RangeNode:
+			walk(x.Pipe)
+			walk(x.List)
+			walk(x.ElseList)
+		case *parse.WithNode:
+			walk(x.Pipe)
+			walk(x.List)
+			walk(x.ElseList)
+		case *parse.PipeNode:
+			if x == nil {
+				return
+			}
+			for _, cmd := range x.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range x.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			seen["."+strings.Join(x.Ident, ".")] = struct{}{}
+		}
+	}
+	walk(tree.Root)
+
+	vars := make([]string, 0, len(seen))
+	for v := range seen {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+	return vars
+}
@@ -1,14 +1,11 @@
 package utils
 
 import (
+	"crypto/subtle"
 	"net"
 	"net/http"
 	"os"
 	"strings"
-	"sync"
-	"time"
-
-	"golang.org/x/time/rate"
 )
 
 // IPWhitelistMiddleware restricts access to allowed IPs
@@ -48,29 +45,21 @@ func IPWhitelistMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// RateLimitMiddleware limits requests per IP
-func RateLimitMiddleware(next http.Handler) http.Handler {
-	// Map to store rate limiters per IP
-	limiters := make(map[string]*rate.Limiter)
-	var mu sync.Mutex
-
-	// Create a new limiter: 10 requests per minute
-	limiter := rate.NewLimiter(rate.Every(time.Minute/10), 10)
-
+// AdminAuthMiddleware requires the X-Admin-Key header to match ADMIN_API_KEY,
+// so the admin endpoints (ban management, template preview) need a separate
+// credential instead of riding on the same IP allowlist/CSRF/rate-limit
+// checks every other API client satisfies. A blank ADMIN_API_KEY locks the
+// admin routes out entirely rather than leaving them open by default.
+func AdminAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		clientIP := GetRealIP(r)
-		mu.Lock()
-		l, exists := limiters[clientIP]
-		if !exists {
-			l = limiter
-			limiters[clientIP] = l
-		}
-		mu.Unlock()
+		want := os.Getenv("ADMIN_API_KEY")
+		got := r.Header.Get("X-Admin-Key")
 
-		if err := l.Wait(r.Context()); err != nil {
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		if want == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			http.Error(w, "Access denied", http.StatusForbidden)
 			return
 		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -85,4 +74,4 @@ func GetRealIP(r *http.Request) string {
 	}
 	host, _, _ := net.SplitHostPort(r.RemoteAddr)
 	return host
-}
\ No newline at end of file
+}
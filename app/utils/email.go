@@ -1,101 +1,91 @@
 package utils
 
 import (
-	"axcommutator/app/config"
-	"bytes"
-	"encoding/base64"
+	"context"
 	"fmt"
 	"net/mail"
-	"net/smtp"
-	"path/filepath"
 	"os"
-	// "log"
+	"path/filepath"
+
+	"axcommutator/app/config"
+	"axcommutator/app/utils/mailer"
+
+	"github.com/h2non/filetype"
 )
 
 // EmailAttachment represents an attachment in an email
 type EmailAttachment struct {
-	Name    string
-	Content []byte
+	Name      string
+	Content   []byte
+	MIME      string // sniffed from Content when empty
+	ContentID string // set for inline images referenced from an HTML body
 }
 
-// SendOrderEmail sends a MIME email with optional attachments
-func SendOrderEmail(service config.ServiceConfig, subject, body, recipient string, attachments []EmailAttachment) error {
+// SendOrderEmail sends an email (optionally HTML, with inline images and
+// attachments) through the mailer package, which handles MIME assembly,
+// STARTTLS and DKIM signing for service.
+func SendOrderEmail(ctx context.Context, service config.ServiceConfig, subject, textBody, htmlBody, recipient string, attachments []EmailAttachment) error {
 	if _, err := mail.ParseAddress(recipient); err != nil {
 		return fmt.Errorf("invalid recipient email: %w", err)
 	}
 
-	var msg bytes.Buffer
-	boundary := "AXCOMMUTATOR-MIME-BOUNDARY"
+	client, err := mailer.NewClient(service)
+	if err != nil {
+		return fmt.Errorf("failed to build mail client: %w", err)
+	}
 
-	// === Headers ===
-	msg.WriteString(fmt.Sprintf("From: %s\r\n", service.SMTP.From))
-	msg.WriteString(fmt.Sprintf("To: %s\r\n", recipient))
-	if service.SMTP.Admin != "" {
-		msg.WriteString(fmt.Sprintf("Bcc: %s\r\n", service.SMTP.Admin))
+	msg := mailer.Message{
+		From:    service.SMTP.From,
+		To:      []string{recipient},
+		Subject: subject,
+		Text:    textBody,
+		HTML:    htmlBody,
 	}
-	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
-	msg.WriteString("MIME-Version: 1.0\r\n")
-	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\n", boundary))
-	msg.WriteString("\r\n")
-
-	// === Body Part ===
-	msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
-	msg.WriteString("Content-Transfer-Encoding: 7bit\r\n")
-	msg.WriteString("\r\n")
-	msg.WriteString(body + "\r\n")
-
-	// === Attachments ===
+
 	for _, a := range attachments {
-		msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-		msg.WriteString("Content-Type: application/pdf\r\n")
-		msg.WriteString("Content-Transfer-Encoding: base64\r\n")
-		msg.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=\"%s\"\r\n", a.Name))
-		msg.WriteString("\r\n")
-
-		encoded := base64.StdEncoding.EncodeToString(a.Content)
-		for i := 0; i < len(encoded); i += 76 {
-			end := i + 76
-			if end > len(encoded) {
-				end = len(encoded)
-			}
-			msg.WriteString(encoded[i:end] + "\r\n")
+		if a.ContentID != "" {
+			msg.Inline = append(msg.Inline, mailer.InlineImage{
+				ContentID: a.ContentID,
+				Name:      a.Name,
+				Content:   a.Content,
+				MIME:      a.MIME,
+			})
+			continue
 		}
+		msg.Attachments = append(msg.Attachments, mailer.Attachment{
+			Name:    a.Name,
+			Content: a.Content,
+			MIME:    a.MIME,
+		})
 	}
 
-	// === End Boundary ===
-	msg.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
-
-	// === Send Email ===
-	smtpAddr := fmt.Sprintf("%s:%s", service.SMTP.Host, service.SMTP.Port)
-	auth := smtp.PlainAuth("", service.SMTP.User, service.SMTP.Password, service.SMTP.Host)
-
-	recipients := []string{recipient}
-	if service.SMTP.Admin != "" {
-		recipients = append(recipients, service.SMTP.Admin)
-	}
-
-	if err := smtp.SendMail(smtpAddr, auth, service.SMTP.From, recipients, msg.Bytes()); err != nil {
+	if err := client.Send(ctx, msg); err != nil {
 		return fmt.Errorf("SMTP send failed: %w", err)
 	}
 
 	return nil
 }
 
-// PrepareAttachments prepares email attachments from temporary files
+// PrepareAttachments prepares email attachments from temporary files,
+// sniffing each file's real MIME type instead of assuming PDF.
 func PrepareAttachments(filePaths map[string]string) ([]EmailAttachment, error) {
 	var attachments []EmailAttachment
 
 	for _, path := range filePaths {
 		content, err := os.ReadFile(path)
 		if err != nil {
-			// log.Printf("Failed to read attachment %s: %v", path, err)
 			continue
 		}
 
+		mimeType := "application/octet-stream"
+		if kind, err := filetype.Match(content); err == nil && kind != filetype.Unknown {
+			mimeType = kind.MIME.Value
+		}
+
 		attachments = append(attachments, EmailAttachment{
 			Name:    filepath.Base(path),
 			Content: content,
+			MIME:    mimeType,
 		})
 	}
 
@@ -110,4 +100,4 @@ func PrepareAttachments(filePaths map[string]string) ([]EmailAttachment, error)
 func ValidateEmail(email string) bool {
 	_, err := mail.ParseAddress(email)
 	return err == nil
-}
\ No newline at end of file
+}
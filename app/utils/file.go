@@ -7,13 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"mime"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
+	"axcommutator/app/storage"
+
 	"github.com/google/uuid"
 	"github.com/h2non/filetype"
 )
@@ -23,13 +23,17 @@ var (
 	ErrInvalidPDF      = errors.New("invalid PDF file")
 	ErrFileTooLarge    = errors.New("file size exceeds limit")
 	ErrInvalidFileType = errors.New("invalid file type")
+	ErrFileNotFound    = errors.New("file not found")
 )
 
 // Constants for file handling configuration
 const (
-	MaxFileSize     = 10 << 20 // 10MB maximum file size
-	PDFMagicNumber  = "%PDF-"  // PDF file signature
+	PDFMagicNumber  = "%PDF-"        // PDF file signature
 	CleanupInterval = 24 * time.Hour // Default expiration time
+
+	imageSizeLimit   = 2 << 20  // 2MB for photos
+	docSizeLimit     = 10 << 20 // 10MB for PDFs and office documents
+	archiveSizeLimit = 25 << 20 // 25MB for zip bundles
 )
 
 // FileInfo contains metadata for stored files
@@ -44,6 +48,60 @@ type FileInfo struct {
 	ExpiresAt time.Time // Scheduled deletion time
 }
 
+// Validator confirms whether data genuinely matches a registered MIME
+// type (beyond a naive content-type sniff) and how large such a file may
+// be.
+type Validator interface {
+	Validate(data []byte) bool
+	MaxSize() int64
+}
+
+// matchValidator confirms a file by checking that filetype.Match's
+// detected MIME equals the one it was registered under.
+type matchValidator struct {
+	mime    string
+	maxSize int64
+}
+
+func (v matchValidator) Validate(data []byte) bool {
+	kind, err := filetype.Match(data)
+	if err != nil {
+		return false
+	}
+	return kind.MIME.Value == v.mime
+}
+
+func (v matchValidator) MaxSize() int64 {
+	return v.maxSize
+}
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]Validator{}
+)
+
+// RegisterValidator associates a Validator with a MIME type, so FileStore
+// can confirm uploads of that type and enforce its size limit. Third-party
+// upload types register here, e.g.:
+//
+//	utils.RegisterValidator("application/epub+zip", myValidator{})
+func RegisterValidator(mimeType string, v Validator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[mimeType] = v
+}
+
+func init() {
+	RegisterValidator("application/pdf", matchValidator{mime: "application/pdf", maxSize: docSizeLimit})
+	RegisterValidator("image/png", matchValidator{mime: "image/png", maxSize: imageSizeLimit})
+	RegisterValidator("image/jpeg", matchValidator{mime: "image/jpeg", maxSize: imageSizeLimit})
+	RegisterValidator(
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		matchValidator{mime: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", maxSize: docSizeLimit},
+	)
+	RegisterValidator("application/zip", matchValidator{mime: "application/zip", maxSize: archiveSizeLimit})
+}
+
 // ValidatePDF checks if the data contains a valid PDF file
 // by verifying both the magic number and MIME type
 func ValidatePDF(data []byte) bool {
@@ -51,63 +109,116 @@ func ValidatePDF(data []byte) bool {
 	if len(data) < len(PDFMagicNumber) {
 		return false
 	}
-	
+
 	// Verify PDF signature and MIME type
 	return bytes.HasPrefix(data, []byte(PDFMagicNumber)) &&
 		filetype.IsMIME(data, "application/pdf")
 }
 
-// SaveTempFile saves data to a temporary file with automatic cleanup
-// Returns FileInfo with metadata or error if operation fails
-func SaveTempFile(data []byte, prefix string) (*FileInfo, error) {
-	// Validate file size limit
-	if len(data) > MaxFileSize {
-		return nil, ErrFileTooLarge
-	}
+// FileStore persists validated uploads to disk and records each one with
+// a storage.Janitor, which survives a restart and reclaims expired files
+// on its own ticker instead of a goroutine per file.
+type FileStore struct {
+	dir     string
+	janitor *storage.Janitor
+}
 
-	// Ensure storage directory exists
-	storagePath := filepath.Join("app", "storage", "temp")
-	if err := os.MkdirAll(storagePath, 0755); err != nil {
+// NewFileStore creates a FileStore rooted at dir, creating it if missing.
+// janitor tracks expirations for every file Save writes into dir, and
+// must be rooted at the same dir so its startup reconciliation sees them.
+func NewFileStore(dir string, janitor *storage.Janitor) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create storage directory: %w", err)
 	}
+	return &FileStore{
+		dir:     dir,
+		janitor: janitor,
+	}, nil
+}
+
+// Save validates data against its registered Validator, picks the correct
+// extension via filetype, and writes it to disk. If allowedMIMEs is given,
+// the detected MIME type must also be one of them. The file is recorded
+// with the janitor for automatic deletion after CleanupInterval.
+func (fs *FileStore) Save(data []byte, prefix string, allowedMIMEs ...string) (*FileInfo, error) {
+	kind, err := filetype.Match(data)
+	if err != nil || kind == filetype.Unknown {
+		return nil, ErrInvalidFileType
+	}
+
+	validatorsMu.RLock()
+	v, known := validators[kind.MIME.Value]
+	validatorsMu.RUnlock()
+	if !known || !v.Validate(data) {
+		return nil, ErrInvalidFileType
+	}
+
+	if len(allowedMIMEs) > 0 && !Contains(allowedMIMEs, kind.MIME.Value) {
+		return nil, ErrInvalidFileType
+	}
+
+	if int64(len(data)) > v.MaxSize() {
+		return nil, ErrFileTooLarge
+	}
 
-	// Generate unique filename using UUID
 	fileID := uuid.New().String()
-	fileName := prefix + "_" + fileID + ".pdf"
-	filePath := filepath.Join(storagePath, fileName)
+	fileName := fmt.Sprintf("%s_%s.%s", prefix, fileID, kind.Extension)
+	filePath := filepath.Join(fs.dir, fileName)
 
-	// Write file contents with restricted permissions
 	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	// Calculate SHA-256 checksum for content verification
 	hash := sha256.Sum256(data)
-	hashStr := hex.EncodeToString(hash[:])
-
-	// Detect actual MIME type for security validation
-	mimeType := http.DetectContentType(data)
-	if !strings.HasPrefix(mimeType, "application/pdf") {
-		os.Remove(filePath) // Clean up invalid file
-		return nil, ErrInvalidFileType
-	}
 
-	// Prepare file metadata
-	fileInfo := &FileInfo{
+	info := &FileInfo{
 		UUID:      fileID,
 		Path:      filePath,
 		Name:      fileName,
 		Size:      int64(len(data)),
-		Sha256:    hashStr,
-		MimeType:  mimeType,
+		Sha256:    hex.EncodeToString(hash[:]),
+		MimeType:  kind.MIME.Value,
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(CleanupInterval),
 	}
 
-	// Schedule automatic cleanup
-	go scheduleFileDeletion(fileInfo.Path, CleanupInterval)
+	if err := fs.janitor.Put(storage.FileRecord{
+		UUID:      info.UUID,
+		Path:      info.Path,
+		Sha256:    info.Sha256,
+		MimeType:  info.MimeType,
+		ExpiresAt: info.ExpiresAt,
+	}); err != nil {
+		os.Remove(filePath)
+		return nil, fmt.Errorf("failed to persist file record: %w", err)
+	}
 
-	return fileInfo, nil
+	return info, nil
+}
+
+// Get returns the metadata for a previously saved file, so it can be
+// re-served without the caller needing to track the path itself.
+func (fs *FileStore) Get(fileID string) (*FileInfo, error) {
+	rec, err := fs.janitor.Get(fileID)
+	if errors.Is(err, storage.ErrRecordNotFound) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		UUID:      rec.UUID,
+		Path:      rec.Path,
+		Name:      filepath.Base(rec.Path),
+		Sha256:    rec.Sha256,
+		MimeType:  rec.MimeType,
+		ExpiresAt: rec.ExpiresAt,
+	}, nil
+}
+
+// Delete removes a file immediately instead of waiting for the janitor's sweep.
+func (fs *FileStore) Delete(fileID string) error {
+	return fs.janitor.Delete(fileID)
 }
 
 // CleanOldFiles removes files older than specified duration
@@ -140,22 +251,3 @@ func CleanOldFiles(dir string, olderThan time.Duration) error {
 
 	return nil
 }
-
-// GetFileExtension returns appropriate file extension
-// for given MIME type using system mime database
-func GetFileExtension(mimeType string) string {
-	exts, err := mime.ExtensionsByType(mimeType)
-	if err != nil || len(exts) == 0 {
-		return ".bin" // Default extension
-	}
-	return exts[0] // Return first matching extension
-}
-
-// scheduleFileDeletion deletes file after specified duration
-// Handles errors silently with logging
-func scheduleFileDeletion(filePath string, duration time.Duration) {
-	time.Sleep(duration)
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-		log.Printf("Failed to delete file %s: %v", filePath, err)
-	}
-}
\ No newline at end of file
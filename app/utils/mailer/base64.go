@@ -0,0 +1,19 @@
+package mailer
+
+import "encoding/base64"
+
+// base64Lines base64-encodes content and wraps it at the 76-column limit
+// RFC 2045 requires for base64 body parts.
+func base64Lines(content []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	out := make([]byte, 0, len(encoded)+len(encoded)/76*2)
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		out = append(out, encoded[i:end]...)
+		out = append(out, '\r', '\n')
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+
+	"axcommutator/app/config"
+)
+
+// defaultDialTimeout bounds the initial TCP connect when ctx carries no
+// deadline of its own.
+const defaultDialTimeout = 15 * time.Second
+
+// Client sends Messages through a single service's SMTP settings,
+// negotiating STARTTLS when the server advertises it and DKIM-signing the
+// result when the service has signing configured.
+type Client struct {
+	host, port  string
+	from, admin string
+	auth        smtp.Auth
+	signer      *dkimSigner
+}
+
+// NewClient builds a Client from a service's SMTP settings. DKIM signing
+// is enabled automatically when DKIMPrivateKeyPath/Selector/Domain are all
+// set on the service.
+func NewClient(service config.ServiceConfig) (*Client, error) {
+	signer, err := newDKIMSigner(
+		service.SMTP.DKIMPrivateKeyPath,
+		service.SMTP.DKIMSelector,
+		service.SMTP.DKIMDomain,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		host:   service.SMTP.Host,
+		port:   service.SMTP.Port,
+		from:   service.SMTP.From,
+		admin:  service.SMTP.Admin,
+		auth:   newAuth(service.SMTP.AuthMethod, service.SMTP.User, service.SMTP.Password, service.SMTP.Host),
+		signer: signer,
+	}, nil
+}
+
+// Send renders msg, DKIM-signs it if configured, and delivers it over
+// STARTTLS when the server offers it (falling back to plaintext AUTH only
+// if it doesn't). ctx bounds the whole dial+auth+send sequence.
+func (c *Client) Send(ctx context.Context, msg Message) error {
+	if msg.From == "" {
+		msg.From = c.from
+	}
+	if c.admin != "" {
+		msg.Bcc = append(msg.Bcc, c.admin)
+	}
+
+	raw, err := build(msg)
+	if err != nil {
+		return fmt.Errorf("mailer: failed to build message: %w", err)
+	}
+
+	raw, err = c.signer.sign(raw)
+	if err != nil {
+		return err
+	}
+
+	return c.deliver(ctx, msg, raw)
+}
+
+func (c *Client) deliver(ctx context.Context, msg Message, raw []byte) error {
+	addr := net.JoinHostPort(c.host, c.port)
+
+	dialer := &net.Dialer{Timeout: defaultDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("mailer: dial failed: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// smtp.Client has no context-aware API of its own, so the rest of the
+	// session (STARTTLS, AUTH, MAIL/RCPT/DATA) is bounded by closing the
+	// underlying conn the moment ctx is done, not just by the deadline set
+	// above — this also covers a ctx canceled without a deadline.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mailer: smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		tlsConfig := &tls.Config{ServerName: c.host}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("mailer: STARTTLS failed: %w", err)
+		}
+	}
+
+	if c.auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(c.auth); err != nil {
+				return fmt.Errorf("mailer: auth failed: %w", err)
+			}
+		}
+	}
+
+	if err := client.Mail(msg.From); err != nil {
+		return fmt.Errorf("mailer: MAIL FROM failed: %w", err)
+	}
+
+	recipients := append(append([]string{}, msg.To...), msg.Bcc...)
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("mailer: RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mailer: DATA failed: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		w.Close()
+		return fmt.Errorf("mailer: write failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mailer: finalizing message failed: %w", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,40 @@
+// Package mailer is a small, dependency-light replacement for the
+// hand-rolled MIME builder that used to live in utils.SendOrderEmail: it
+// builds multipart/alternative (+ related/mixed) messages with correct
+// per-attachment MIME sniffing, Content-ID inline images, STARTTLS
+// negotiation and pluggable SMTP auth.
+package mailer
+
+import "time"
+
+// Attachment is a file attached to the outgoing message. MIME is sniffed
+// from Content by Client.Send when left blank.
+type Attachment struct {
+	Name    string
+	Content []byte
+	MIME    string
+}
+
+// InlineImage is attached the same way as Attachment but referenced from
+// the HTML body via "cid:<ContentID>" and carried in multipart/related
+// instead of multipart/mixed.
+type InlineImage struct {
+	ContentID string
+	Name      string
+	Content   []byte
+	MIME      string
+}
+
+// Message is a single outbound email, independent of any particular
+// transport concern (auth, TLS, DKIM live on the Client).
+type Message struct {
+	From        string
+	To          []string
+	Bcc         []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	Inline      []InlineImage
+	Date        time.Time
+}
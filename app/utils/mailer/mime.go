@@ -0,0 +1,201 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/h2non/filetype"
+)
+
+// sniffMIME returns typ if already set, otherwise detects it from content
+// via filetype, the same library utils.FileStore already relies on.
+func sniffMIME(typ string, content []byte) string {
+	if typ != "" {
+		return typ
+	}
+	if kind, err := filetype.Match(content); err == nil && kind != filetype.Unknown {
+		return kind.MIME.Value
+	}
+	return "application/octet-stream"
+}
+
+// renderMultipart writes a complete multipart/<subtype> body by calling fill
+// with a *multipart.Writer, and returns its Content-Type header value
+// together with the rendered bytes.
+func renderMultipart(subtype string, fill func(w *multipart.Writer) error) (string, []byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := fill(w); err != nil {
+		return "", nil, err
+	}
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+	contentType := fmt.Sprintf("multipart/%s; boundary=%q", subtype, w.Boundary())
+	return contentType, buf.Bytes(), nil
+}
+
+// writeNestedPart writes a previously-rendered multipart body as a single
+// part of the enclosing writer, carrying over its Content-Type.
+func writeNestedPart(w *multipart.Writer, contentType string, body []byte) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(body)
+	return err
+}
+
+// buildAlternative renders the text/html body pair. With no HTML set it
+// degrades to a single text/plain part and is not itself multipart.
+func buildAlternative(msg Message) (string, []byte, error) {
+	if msg.HTML == "" {
+		var buf bytes.Buffer
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(msg.Text)); err != nil {
+			return "", nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return "", nil, err
+		}
+		return "text/plain; charset=utf-8", buf.Bytes(), nil
+	}
+
+	return renderMultipart("alternative", func(w *multipart.Writer) error {
+		if msg.Text != "" {
+			if err := writeTextPart(w, msg.Text); err != nil {
+				return err
+			}
+		}
+		return writeHTMLPart(w, msg.HTML)
+	})
+}
+
+// build renders msg into a complete RFC 5322 message (headers + MIME body).
+func build(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	date := msg.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	if len(msg.Bcc) > 0 {
+		fmt.Fprintf(&buf, "Bcc: %s\r\n", strings.Join(msg.Bcc, ", "))
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	contentType, body, err := buildAlternative(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(msg.Inline) > 0 && msg.HTML != "" {
+		innerType, innerBody := contentType, body
+		contentType, body, err = renderMultipart("related", func(w *multipart.Writer) error {
+			if err := writeNestedPart(w, innerType, innerBody); err != nil {
+				return err
+			}
+			for _, img := range msg.Inline {
+				if err := writeInlinePart(w, img); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(msg.Attachments) > 0 {
+		innerType, innerBody := contentType, body
+		contentType, body, err = renderMultipart("mixed", func(w *multipart.Writer) error {
+			if err := writeNestedPart(w, innerType, innerBody); err != nil {
+				return err
+			}
+			for _, a := range msg.Attachments {
+				if err := writeAttachment(w, a); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Fprintf(&buf, "Content-Type: %s\r\n\r\n", contentType)
+	buf.Write(body)
+
+	return buf.Bytes(), nil
+}
+
+func writeTextPart(w *multipart.Writer, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func writeHTMLPart(w *multipart.Writer, html string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(html)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func writeInlinePart(w *multipart.Writer, img InlineImage) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {sniffMIME(img.MIME, img.Content)},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("inline; filename=%q", img.Name)},
+		"Content-ID":                {fmt.Sprintf("<%s>", img.ContentID)},
+	}
+	return writeBase64Part(w, header, img.Content)
+}
+
+func writeAttachment(w *multipart.Writer, a Attachment) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {sniffMIME(a.MIME, a.Content)},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Name)},
+	}
+	return writeBase64Part(w, header, a.Content)
+}
+
+func writeBase64Part(w *multipart.Writer, header textproto.MIMEHeader, content []byte) error {
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(base64Lines(content))
+	return err
+}
@@ -0,0 +1,369 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// RouteLimit is the token-bucket configuration for one route pattern.
+type RouteLimit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+const (
+	defaultBurst = 10
+
+	// A client crossing banThreshold consecutive 4xx responses within
+	// banWindow is promoted to the ban list.
+	banThreshold    = 5
+	banWindow       = 10 * time.Minute
+	banBaseDuration = 5 * time.Minute
+	maxBanDuration  = 24 * time.Hour
+
+	janitorInterval = 10 * time.Minute
+	limiterIdleTTL  = 30 * time.Minute
+)
+
+// defaultRate is 10 requests/minute, matching the historical global limiter.
+var defaultRate = rate.Every(time.Minute / 10)
+
+// builtinRouteLimits are the out-of-the-box per-route defaults; /order is
+// far stricter than /health since it triggers email/Telegram/DB work.
+var builtinRouteLimits = map[string]RouteLimit{
+	"/api/v1/order":          {Rate: rate.Every(20 * time.Second), Burst: 3},
+	"/api/v1/cookie-consent": {Rate: rate.Every(3 * time.Second), Burst: 20},
+	"/api/v1/health":         {Rate: rate.Every(time.Second), Burst: 20},
+	"/api/v1/csrf-token":     {Rate: rate.Every(time.Second), Burst: 20},
+}
+
+// limiterEntry pairs a token bucket with the last time it was touched, so
+// the janitor can garbage-collect limiters nobody has used in a while.
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// offenderState tracks an IP's consecutive-4xx streak within banWindow.
+type offenderState struct {
+	count       int
+	windowStart time.Time
+}
+
+// RateLimiter is a per-IP, per-route token-bucket limiter with a
+// SQLite-persisted ban list and exponential backoff for repeat offenders.
+// It replaces the old RateLimitMiddleware, whose shared `l = limiter`
+// assignment handed every client the same bucket.
+type RateLimiter struct {
+	db          *sql.DB
+	routeLimits map[string]RouteLimit
+
+	mu       sync.Mutex
+	limiters map[string]*limiterEntry
+
+	offendersMu sync.Mutex
+	offenders   map[string]*offenderState
+
+	logger *zap.Logger
+	stop   chan struct{}
+}
+
+// NewRateLimiter builds a RateLimiter, ensures its ban-list table exists,
+// and loads per-route overrides from RATE_LIMIT_<ROUTE>_RPM/_BURST env vars.
+func NewRateLimiter(db *sql.DB, logger *zap.Logger) (*RateLimiter, error) {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS rate_limit_bans (
+			ip         TEXT PRIMARY KEY,
+			ban_count  INTEGER NOT NULL DEFAULT 0,
+			reason     TEXT,
+			banned_at  DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create rate_limit_bans table: %w", err)
+	}
+
+	return &RateLimiter{
+		db:          db,
+		routeLimits: loadRouteLimits(),
+		limiters:    make(map[string]*limiterEntry),
+		offenders:   make(map[string]*offenderState),
+		logger:      logger,
+		stop:        make(chan struct{}),
+	}, nil
+}
+
+func loadRouteLimits() map[string]RouteLimit {
+	limits := make(map[string]RouteLimit, len(builtinRouteLimits))
+	for path, def := range builtinRouteLimits {
+		limit := def
+		key := routeEnvKey(path)
+
+		if v := os.Getenv("RATE_LIMIT_" + key + "_RPM"); v != "" {
+			if rpm, err := strconv.ParseFloat(v, 64); err == nil && rpm > 0 {
+				limit.Rate = rate.Limit(rpm / 60.0)
+			}
+		}
+		if v := os.Getenv("RATE_LIMIT_" + key + "_BURST"); v != "" {
+			if burst, err := strconv.Atoi(v); err == nil && burst > 0 {
+				limit.Burst = burst
+			}
+		}
+		limits[path] = limit
+	}
+	return limits
+}
+
+func routeEnvKey(path string) string {
+	key := strings.ToUpper(strings.Trim(path, "/"))
+	return strings.NewReplacer("/", "_", "-", "_").Replace(key)
+}
+
+// StartJanitor runs a background goroutine that evicts limiters idle
+// longer than limiterIdleTTL so the map doesn't grow unbounded. Call Stop
+// to end it.
+func (rl *RateLimiter) StartJanitor() {
+	go func() {
+		ticker := time.NewTicker(janitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				rl.evictIdleLimiters()
+			case <-rl.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the janitor goroutine started by StartJanitor.
+func (rl *RateLimiter) Stop() {
+	close(rl.stop)
+}
+
+func (rl *RateLimiter) evictIdleLimiters() {
+	cutoff := time.Now().Add(-limiterIdleTTL)
+
+	rl.mu.Lock()
+	for key, entry := range rl.limiters {
+		if entry.lastSeen.Before(cutoff) {
+			delete(rl.limiters, key)
+		}
+	}
+	rl.mu.Unlock()
+
+	rl.offendersMu.Lock()
+	for ip, state := range rl.offenders {
+		if state.windowStart.Before(cutoff) {
+			delete(rl.offenders, ip)
+		}
+	}
+	rl.offendersMu.Unlock()
+}
+
+func (rl *RateLimiter) limiterFor(ip, route string) *rate.Limiter {
+	key := ip + "|" + route
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	entry, ok := rl.limiters[key]
+	if !ok {
+		limit := rl.routeLimits[route]
+		if limit.Rate == 0 {
+			limit = RouteLimit{Rate: defaultRate, Burst: defaultBurst}
+		}
+		entry = &limiterEntry{limiter: rate.NewLimiter(limit.Rate, limit.Burst)}
+		rl.limiters[key] = entry
+	}
+	entry.lastSeen = time.Now()
+	return entry.limiter
+}
+
+// Middleware enforces the per-IP, per-route limit, consults the ban list,
+// and tracks consecutive 4xx responses to auto-ban abusive clients.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := GetRealIP(r)
+
+		if until, banned := rl.checkBanned(ip); banned {
+			rl.respondTooManyRequests(w, time.Until(until))
+			return
+		}
+
+		limiter := rl.limiterFor(ip, routePattern(r))
+		reservation := limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			rl.respondTooManyRequests(w, delay)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= 400 && rec.status < 500 {
+			rl.recordOffense(ip)
+		} else {
+			rl.clearOffenses(ip)
+		}
+	})
+}
+
+func (rl *RateLimiter) respondTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+}
+
+func routePattern(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return r.URL.Path
+}
+
+// recordOffense bumps ip's consecutive-4xx streak and bans it once
+// banThreshold is crossed inside banWindow.
+func (rl *RateLimiter) recordOffense(ip string) {
+	rl.offendersMu.Lock()
+	state, ok := rl.offenders[ip]
+	if !ok || time.Since(state.windowStart) > banWindow {
+		state = &offenderState{windowStart: time.Now()}
+		rl.offenders[ip] = state
+	}
+	state.count++
+	count := state.count
+	rl.offendersMu.Unlock()
+
+	if count >= banThreshold {
+		rl.offendersMu.Lock()
+		delete(rl.offenders, ip)
+		rl.offendersMu.Unlock()
+
+		if err := rl.Ban(ip, "exceeded consecutive 4xx threshold"); err != nil && rl.logger != nil {
+			rl.logger.Error("failed to auto-ban IP", zap.String("ip", ip), zap.Error(err))
+		}
+	}
+}
+
+func (rl *RateLimiter) clearOffenses(ip string) {
+	rl.offendersMu.Lock()
+	delete(rl.offenders, ip)
+	rl.offendersMu.Unlock()
+}
+
+// checkBanned reports whether ip currently has an unexpired ban.
+func (rl *RateLimiter) checkBanned(ip string) (time.Time, bool) {
+	var expiresAt time.Time
+	err := rl.db.QueryRow(
+		"SELECT expires_at FROM rate_limit_bans WHERE ip = ?", ip,
+	).Scan(&expiresAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if time.Now().After(expiresAt) {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// Ban bans ip with exponential backoff: each repeat ban doubles the
+// previous duration, capped at maxBanDuration.
+func (rl *RateLimiter) Ban(ip, reason string) error {
+	var priorBans int
+	_ = rl.db.QueryRow("SELECT ban_count FROM rate_limit_bans WHERE ip = ?", ip).Scan(&priorBans)
+
+	duration := banBaseDuration * time.Duration(math.Pow(2, float64(priorBans)))
+	if duration > maxBanDuration {
+		duration = maxBanDuration
+	}
+
+	now := time.Now().UTC()
+	_, err := rl.db.Exec(`
+		INSERT INTO rate_limit_bans (ip, ban_count, reason, banned_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(ip) DO UPDATE SET
+			ban_count = ban_count + 1,
+			reason = excluded.reason,
+			banned_at = excluded.banned_at,
+			expires_at = excluded.expires_at
+	`, ip, priorBans+1, reason, now, now.Add(duration))
+	if err != nil {
+		return fmt.Errorf("failed to persist ban for %s: %w", ip, err)
+	}
+
+	if rl.logger != nil {
+		rl.logger.Warn("banned IP", zap.String("ip", ip), zap.String("reason", reason), zap.Duration("duration", duration))
+	}
+	return nil
+}
+
+// Unban removes any ban recorded for ip.
+func (rl *RateLimiter) Unban(ip string) error {
+	_, err := rl.db.Exec("DELETE FROM rate_limit_bans WHERE ip = ?", ip)
+	if err != nil {
+		return fmt.Errorf("failed to unban %s: %w", ip, err)
+	}
+	return nil
+}
+
+// BanRecord is a row of the ban list, exposed for the admin listing endpoint.
+type BanRecord struct {
+	IP        string    `json:"ip"`
+	BanCount  int       `json:"banCount"`
+	Reason    string    `json:"reason"`
+	BannedAt  time.Time `json:"bannedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ListBans returns every currently-active ban.
+func (rl *RateLimiter) ListBans() ([]BanRecord, error) {
+	rows, err := rl.db.Query(
+		"SELECT ip, ban_count, reason, banned_at, expires_at FROM rate_limit_bans WHERE expires_at > ? ORDER BY banned_at DESC",
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []BanRecord
+	for rows.Next() {
+		var b BanRecord
+		if err := rows.Scan(&b.IP, &b.BanCount, &b.Reason, &b.BannedAt, &b.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ban record: %w", err)
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// statusRecorder captures the status code written by the wrapped handler
+// so Middleware can decide whether to count the request as an offense.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"axcommutator/app/config"
+	"axcommutator/app/templates"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,10 +17,16 @@ import (
 	"time"
 )
 
+// renderer renders Telegram message templates; it holds no state, so a
+// single package-level instance is shared across every send.
+var renderer = templates.NewRenderer()
+
 const (
-	telegramAPITimeout = 15 * time.Second
-	telegramAPIBaseURL = "https://api.telegram.org/bot%s/sendMessage"
-	maxMessageLength   = 4096
+	telegramAPITimeout    = 15 * time.Second
+	telegramUploadTimeout = 60 * time.Second
+	telegramAPIBaseURL    = "https://api.telegram.org/bot%s/%s"
+	maxMessageLength      = 4096
+	maxRetryAttempts      = 3
 )
 
 var (
@@ -26,26 +34,39 @@ var (
 	ErrInvalidTemplate       = errors.New("invalid template format")
 	ErrMessageTooLong        = errors.New("message exceeds maximum length")
 	ErrAPIRequestFailed      = errors.New("telegram API request failed")
+	ErrRetriesExhausted      = errors.New("telegram API retries exhausted")
 )
 
+// TelegramResponse is the standard Telegram Bot API response envelope.
+// Parameters is only populated on a 429 flood-wait error.
 type TelegramResponse struct {
 	OK          bool   `json:"ok"`
 	Description string `json:"description"`
 	ErrorCode   int    `json:"error_code"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
 }
 
-// SendTelegramNotification sends a localized notification to Telegram
-func SendTelegramNotification(service config.ServiceConfig, lang string, data map[string]interface{}) error {
+// SendTelegramNotification sends a localized notification to Telegram.
+// chatID is the resolved recipient (typically from the bot's verified
+// recipient registry); if empty, it falls back to the service's static
+// Telegram.ChatID so callers that haven't adopted the registry yet still work.
+func SendTelegramNotification(ctx context.Context, service config.ServiceConfig, chatID, lang string, data map[string]interface{}) error {
 	if !service.Telegram.Configured() {
 		return ErrTelegramNotConfigured
 	}
 
+	if chatID == "" {
+		chatID = service.Telegram.ChatID
+	}
+
 	template, err := getLocalizedTemplate(service, lang)
 	if err != nil {
 		return fmt.Errorf("template error: %w", err)
 	}
 
-	message, err := renderTemplate(template, data)
+	message, _, err := renderer.Render("telegram", template, lang, data)
 	if err != nil {
 		return fmt.Errorf("template rendering failed: %w", err)
 	}
@@ -56,13 +77,20 @@ func SendTelegramNotification(service config.ServiceConfig, lang string, data ma
 		return fmt.Errorf("%w: %d > %d", ErrMessageTooLong, len(message), maxMessageLength)
 	}
 
-	response, err := sendTelegramRequest(service.Telegram.BotToken, service.Telegram.ChatID, message)
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     message,
+		"parse_mode":               "MarkdownV2",
+		"disable_web_page_preview": true,
+	})
 	if err != nil {
-		return fmt.Errorf("send failed: %w", err)
+		return fmt.Errorf("payload marshal failed: %w", err)
 	}
 
-	if !response.OK {
-		return fmt.Errorf("telegram API error: %s (code %d)", response.Description, response.ErrorCode)
+	if _, err := doTelegramRequest(ctx, service.Telegram.BotToken, "sendMessage", func() (io.Reader, string, error) {
+		return bytes.NewReader(jsonData), "application/json", nil
+	}); err != nil {
+		return fmt.Errorf("send failed: %w", err)
 	}
 
 	return nil
@@ -92,16 +120,6 @@ func getLocalizedTemplate(service config.ServiceConfig, lang string) (string, er
 	return template, nil
 }
 
-func renderTemplate(template string, data map[string]interface{}) (string, error) {
-	result := template
-	for key, value := range data {
-		placeholder := "{" + key + "}"
-		strValue := fmt.Sprintf("%v", value)
-		result = strings.ReplaceAll(result, placeholder, strValue)
-	}
-	return result, nil
-}
-
 // EscapeMarkdownV2 escapes all required characters for Telegram MarkdownV2 format
 func EscapeMarkdownV2(text string) string {
 	specials := []string{
@@ -114,95 +132,189 @@ func EscapeMarkdownV2(text string) string {
 	return text
 }
 
-func sendTelegramRequest(botToken, chatID, message string) (*TelegramResponse, error) {
-	payload := map[string]interface{}{
-		"chat_id":                  chatID,
-		"text":                     message,
-		"parse_mode":               "MarkdownV2",
-		"disable_web_page_preview": true,
-	}
+// doTelegramRequest POSTs to the Telegram Bot API, retrying up to
+// maxRetryAttempts times when Telegram responds with a 429 flood-wait,
+// sleeping for the requested retry_after each time. newBody is invoked
+// fresh on every attempt since a streamed multipart body can't be replayed.
+func doTelegramRequest(ctx context.Context, botToken, method string, newBody func() (io.Reader, string, error)) (*TelegramResponse, error) {
+	apiURL := fmt.Sprintf(telegramAPIBaseURL, botToken, method)
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("payload marshal failed: %w", err)
-	}
+	var lastErr error
+	for attempt := 0; attempt <= maxRetryAttempts; attempt++ {
+		body, contentType, err := newBody()
+		if err != nil {
+			return nil, fmt.Errorf("build request body failed: %w", err)
+		}
 
-	client := &http.Client{Timeout: telegramAPITimeout}
-	apiURL := fmt.Sprintf(telegramAPIBaseURL, botToken)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, body)
+		if err != nil {
+			return nil, fmt.Errorf("request build failed: %w", err)
+		}
+		req.Header.Set("Content-Type", contentType)
 
-	resp, err := client.Post(apiURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrAPIRequestFailed, err)
-	}
-	defer resp.Body.Close()
+		client := &http.Client{Timeout: telegramUploadTimeout}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAPIRequestFailed, err)
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("response read failed: %w", err)
-	}
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, fmt.Errorf("response read failed: %w", readErr)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
-	}
+		var parsed TelegramResponse
+		if err := json.Unmarshal(respBody, &parsed); err != nil {
+			return nil, fmt.Errorf("response parse failed: %w", err)
+		}
+
+		if parsed.OK {
+			return &parsed, nil
+		}
+
+		if parsed.ErrorCode == http.StatusTooManyRequests && parsed.Parameters != nil && attempt < maxRetryAttempts {
+			lastErr = fmt.Errorf("telegram flood wait: %s", parsed.Description)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(parsed.Parameters.RetryAfter) * time.Second):
+			}
+			continue
+		}
 
-	var response TelegramResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("response parse failed: %w", err)
+		return nil, fmt.Errorf("telegram API error: %s (code %d)", parsed.Description, parsed.ErrorCode)
 	}
 
-	return &response, nil
+	return nil, fmt.Errorf("%w: %v", ErrRetriesExhausted, lastErr)
 }
 
-// SendTelegramDocument sends a file (PDF, DOC, etc.) to Telegram with caption
-func SendTelegramDocument(botToken, chatID, filePath, caption string) error {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendDocument", botToken)
+// telegramFile describes one file to stream into a multipart request.
+type telegramFile struct {
+	field string
+	name  string
+	path  string
+}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("cannot open file: %w", err)
-	}
-	defer file.Close()
+// buildMultipart streams fields and files into a fresh io.Pipe-backed
+// multipart body in a background goroutine, so a large attachment is never
+// fully buffered in memory the way a bytes.Buffer would require.
+func buildMultipart(fields map[string]string, files []telegramFile) (io.Reader, string, error) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(func() error {
+			for k, v := range fields {
+				if err := writer.WriteField(k, v); err != nil {
+					return err
+				}
+			}
+			for _, f := range files {
+				file, err := os.Open(f.path)
+				if err != nil {
+					return err
+				}
+				part, err := writer.CreateFormFile(f.field, f.name)
+				if err != nil {
+					file.Close()
+					return err
+				}
+				_, copyErr := io.Copy(part, file)
+				file.Close()
+				if copyErr != nil {
+					return copyErr
+				}
+			}
+			return writer.Close()
+		}())
+	}()
+
+	return pr, writer.FormDataContentType(), nil
+}
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+func sendTelegramFile(ctx context.Context, botToken, method, field, chatID, filePath, caption string) error {
+	fields := map[string]string{
+		"chat_id":    chatID,
+		"caption":    EscapeMarkdownV2(caption),
+		"parse_mode": "MarkdownV2",
+	}
+	files := []telegramFile{{field: field, name: filepath.Base(filePath), path: filePath}}
 
-	part, err := writer.CreateFormFile("document", filepath.Base(filePath))
+	_, err := doTelegramRequest(ctx, botToken, method, func() (io.Reader, string, error) {
+		return buildMultipart(fields, files)
+	})
 	if err != nil {
-		return fmt.Errorf("create form file failed: %w", err)
+		return fmt.Errorf("send failed: %w", err)
 	}
-	if _, err = io.Copy(part, file); err != nil {
-		return fmt.Errorf("copy file failed: %w", err)
+	return nil
+}
+
+// SendTelegramDocument sends a file (PDF, DOC, etc.) to Telegram with a
+// caption, streaming the upload so memory use stays bounded regardless of
+// file size.
+func SendTelegramDocument(ctx context.Context, botToken, chatID, filePath, caption string) error {
+	return sendTelegramFile(ctx, botToken, "sendDocument", "document", chatID, filePath, caption)
+}
+
+// SendTelegramPhoto sends an image to Telegram with a caption.
+func SendTelegramPhoto(ctx context.Context, botToken, chatID, filePath, caption string) error {
+	return sendTelegramFile(ctx, botToken, "sendPhoto", "photo", chatID, filePath, caption)
+}
+
+// SendTelegramAudio sends an audio file to Telegram with a caption.
+func SendTelegramAudio(ctx context.Context, botToken, chatID, filePath, caption string) error {
+	return sendTelegramFile(ctx, botToken, "sendAudio", "audio", chatID, filePath, caption)
+}
+
+// TelegramMediaItem is one entry of a sendMediaGroup album; Type is one of
+// Telegram's media kinds ("photo", "video", "document", "audio") and Path
+// is the local file to stream in, referenced internally via Telegram's
+// attach:// scheme.
+type TelegramMediaItem struct {
+	Type    string
+	Path    string
+	Caption string
+}
+
+// SendTelegramMediaGroup sends up to ten files as a single Telegram album.
+func SendTelegramMediaGroup(ctx context.Context, botToken, chatID string, items []TelegramMediaItem) error {
+	if len(items) == 0 {
+		return fmt.Errorf("media group requires at least one item")
 	}
 
-	_ = writer.WriteField("chat_id", chatID)
-	_ = writer.WriteField("caption", EscapeMarkdownV2(caption))
-	_ = writer.WriteField("parse_mode", "MarkdownV2")
-	writer.Close()
+	type mediaEntry struct {
+		Type    string `json:"type"`
+		Media   string `json:"media"`
+		Caption string `json:"caption,omitempty"`
+	}
 
-	req, err := http.NewRequest("POST", apiURL, body)
-	if err != nil {
-		return fmt.Errorf("request build failed: %w", err)
+	media := make([]mediaEntry, 0, len(items))
+	files := make([]telegramFile, 0, len(items))
+	for i, item := range items {
+		attachName := fmt.Sprintf("file%d", i)
+		media = append(media, mediaEntry{
+			Type:    item.Type,
+			Media:   "attach://" + attachName,
+			Caption: EscapeMarkdownV2(item.Caption),
+		})
+		files = append(files, telegramFile{field: attachName, name: filepath.Base(item.Path), path: item.Path})
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{Timeout: telegramAPITimeout}
-	resp, err := client.Do(req)
+	mediaJSON, err := json.Marshal(media)
 	if err != nil {
-		return fmt.Errorf("send failed: %w", err)
+		return fmt.Errorf("media marshal failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram error: %s", respBody)
+	fields := map[string]string{
+		"chat_id": chatID,
+		"media":   string(mediaJSON),
 	}
 
-	var tgResp TelegramResponse
-	if err := json.Unmarshal(respBody, &tgResp); err != nil {
-		return fmt.Errorf("response parse failed: %w", err)
-	}
-	if !tgResp.OK {
-		return fmt.Errorf("telegram error: %s (code %d)", tgResp.Description, tgResp.ErrorCode)
+	if _, err := doTelegramRequest(ctx, botToken, "sendMediaGroup", func() (io.Reader, string, error) {
+		return buildMultipart(fields, files)
+	}); err != nil {
+		return fmt.Errorf("send failed: %w", err)
 	}
 
 	return nil
@@ -1,16 +1,23 @@
 package main
 
 import (
+	"axcommutator/app/config"
 	"axcommutator/app/db"
 	"axcommutator/app/handlers"
+	"axcommutator/app/idempotency"
+	"axcommutator/app/messenger"
+	"axcommutator/app/storage"
+	"axcommutator/app/telegram"
 	"axcommutator/app/utils"
-	"axcommutator/app/config"
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -20,16 +27,57 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCLI(os.Args[2:])
+		return
+	}
+
 	validateEnv()
-	config.LoadServices()
 
 	logger := initLogger()
 	defer logger.Sync()
+	handlers.Logger = logger
+
+	config.LoadServices(logger)
+
+	// Third-party messenger backends (Slack, Discord, Matrix, generic
+	// webhooks, SMS, ...) register here, e.g.:
+	//   messenger.RegisterFactory("slack", slackmsgr.New)
+	logger.Info("Messenger backends available", zap.Strings("backends", messenger.RegisteredNames()))
 
 	db := initDB(logger)
 	defer db.Close()
 
-	r := createRouter(logger)
+	limiter := initRateLimiter(db, logger)
+	defer limiter.Stop()
+	handlers.Limiter = limiter
+
+	idempotencyStore := initIdempotencyStore(db, logger)
+	defer func() {
+		if err := idempotencyStore.Close(); err != nil {
+			logger.Error("Failed to persist idempotency state", zap.Error(err))
+		}
+	}()
+	handlers.Idempotency = idempotencyStore
+
+	fileStore, janitor := initFileStore(logger)
+	defer func() {
+		if err := janitor.Close(); err != nil {
+			logger.Error("Failed to close janitor", zap.Error(err))
+		}
+	}()
+	handlers.Files = fileStore
+
+	if bot := initTelegramBot(logger); bot != nil {
+		handlers.TelegramBot = bot
+		if os.Getenv("TELEGRAM_WEBHOOK") != "true" {
+			botCtx, cancelBot := context.WithCancel(context.Background())
+			defer cancelBot()
+			go bot.Start(botCtx)
+		}
+	}
+
+	r := createRouter(logger, limiter)
 
 	srv := &http.Server{
 		Handler:      r,
@@ -71,7 +119,142 @@ func initDB(logger *zap.Logger) *sql.DB {
 	return db
 }
 
-func createRouter(logger *zap.Logger) *mux.Router {
+func initRateLimiter(db *sql.DB, logger *zap.Logger) *utils.RateLimiter {
+	limiter, err := utils.NewRateLimiter(db, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize rate limiter", zap.Error(err))
+	}
+	limiter.StartJanitor()
+	return limiter
+}
+
+// runMigrateCLI implements `axcomm migrate up|down|status|new <name>`. It
+// opens its own short-lived DB connection rather than going through
+// startServer's lifecycle, since it never serves HTTP traffic.
+func runMigrateCLI(args []string) {
+	logger := initLogger()
+	defer logger.Sync()
+
+	if len(args) < 1 {
+		log.Fatal("usage: axcomm migrate up|down|status|new <name>")
+	}
+
+	if args[0] == "new" {
+		if len(args) < 2 {
+			log.Fatal("usage: axcomm migrate new <name>")
+		}
+		dir := os.Getenv("MIGRATIONS_DIR")
+		if dir == "" {
+			dir = "app/db/migrations"
+		}
+		upPath, downPath, err := db.NewMigration(dir, args[1])
+		if err != nil {
+			logger.Fatal("Failed to scaffold migration", zap.Error(err))
+		}
+		logger.Info("Scaffolded migration", zap.String("up", upPath), zap.String("down", downPath))
+		return
+	}
+
+	conn := initDB(logger)
+	defer conn.Close()
+
+	switch args[0] {
+	case "up":
+		if err := db.MigrateUp(conn, logger); err != nil {
+			logger.Fatal("Migration failed", zap.Error(err))
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				log.Fatalf("invalid step count %q: %v", args[1], err)
+			}
+			steps = n
+		}
+		if err := db.MigrateDown(conn, logger, steps); err != nil {
+			logger.Fatal("Migration rollback failed", zap.Error(err))
+		}
+	case "status":
+		entries, err := db.Status(conn)
+		if err != nil {
+			logger.Fatal("Failed to read migration status", zap.Error(err))
+		}
+		for _, e := range entries {
+			state := "pending"
+			if e.Applied {
+				state = "applied at " + e.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%03d_%s: %s\n", e.Version, e.Name, state)
+		}
+	default:
+		log.Fatalf("unknown migrate subcommand %q", args[0])
+	}
+}
+
+func initIdempotencyStore(db *sql.DB, logger *zap.Logger) *idempotency.Store {
+	store, err := idempotency.NewStore(db, logger, os.Getenv("IDEMPOTENCY_PERSIST_PATH"))
+	if err != nil {
+		logger.Fatal("Failed to initialize idempotency store", zap.Error(err))
+	}
+	store.StartRotationTicker()
+	return store
+}
+
+func initFileStore(logger *zap.Logger) (*utils.FileStore, *storage.Janitor) {
+	dir := os.Getenv("UPLOAD_STORAGE_DIR")
+	if dir == "" {
+		dir = filepath.Join("app", "storage", "temp")
+	}
+
+	dbPath := os.Getenv("JANITOR_DB_PATH")
+	if dbPath == "" {
+		dbPath = filepath.Join("app", "storage", "janitor.db")
+	}
+
+	sweepInterval := 10 * time.Minute
+	if raw := os.Getenv("JANITOR_SWEEP_INTERVAL"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			sweepInterval = parsed
+		} else {
+			logger.Warn("invalid JANITOR_SWEEP_INTERVAL, using default", zap.String("value", raw), zap.Duration("default", sweepInterval))
+		}
+	}
+
+	janitor, err := storage.NewJanitor(dbPath, dir, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize janitor", zap.Error(err))
+	}
+	janitor.StartSweeper(sweepInterval)
+
+	store, err := utils.NewFileStore(dir, janitor)
+	if err != nil {
+		logger.Fatal("Failed to initialize file store", zap.Error(err))
+	}
+	return store, janitor
+}
+
+// initTelegramBot builds the inbound Telegram bot if TELEGRAM_BOT_TOKEN is
+// set; the feature is optional, so a blank token just returns nil.
+func initTelegramBot(logger *zap.Logger) *telegram.Bot {
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil
+	}
+
+	storePath := os.Getenv("TELEGRAM_RECIPIENTS_PATH")
+	if storePath == "" {
+		storePath = "/app/database/telegram_recipients.json"
+	}
+
+	store, err := telegram.NewJSONFileStore(storePath)
+	if err != nil {
+		logger.Fatal("Failed to initialize telegram recipient store", zap.Error(err))
+	}
+	return telegram.NewBot(token, store, logger)
+}
+
+func createRouter(logger *zap.Logger, limiter *utils.RateLimiter) *mux.Router {
 	r := mux.NewRouter()
 
 	r.Use(
@@ -83,13 +266,34 @@ func createRouter(logger *zap.Logger) *mux.Router {
 	api.Use(
 		createCSRFMiddleware(),
 		utils.IPWhitelistMiddleware,
-		utils.RateLimitMiddleware,
+		limiter.Middleware,
 	)
 
 	api.HandleFunc("/order", handlers.HandleProjectOrder).Methods("POST")
 	api.HandleFunc("/cookie-consent", handlers.HandleCookieConsent).Methods("POST")
 	api.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
 	api.HandleFunc("/csrf-token", handlers.GetCSRFToken).Methods("GET")
+	api.HandleFunc("/verify/telegram", handlers.HandleTelegramGeneratePIN).Methods("POST")
+	api.HandleFunc("/verify/telegram/{pin}", handlers.HandleTelegramVerify).Methods("GET")
+
+	// machine is for endpoints called by other servers rather than our own
+	// frontend (Telegram's webhook callback, and similar machine-to-machine
+	// calls) — those callers can never carry this app's CSRF cookie/token,
+	// so it skips CSRF while still enforcing the IP allowlist and rate limit.
+	machine := r.PathPrefix("/api/v1").Subrouter()
+	machine.Use(
+		utils.IPWhitelistMiddleware,
+		limiter.Middleware,
+	)
+	machine.HandleFunc("/telegram/webhook", handlers.HandleTelegramWebhook).Methods("POST")
+
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(utils.AdminAuthMiddleware)
+	admin.HandleFunc("/bans", handlers.HandleAdminListBans).Methods("GET")
+	admin.HandleFunc("/bans", handlers.HandleAdminBanIP).Methods("POST")
+	admin.HandleFunc("/bans/{ip}", handlers.HandleAdminUnbanIP).Methods("DELETE")
+	admin.HandleFunc("/templates", handlers.HandleTemplateCatalog).Methods("GET")
+	admin.HandleFunc("/templates/{service}/{id}", handlers.HandleTemplatePreview).Methods("GET")
 
 	return r
 }
@@ -149,7 +353,7 @@ func startServer(srv *http.Server, logger *zap.Logger) {
 func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// start := time.Now() 
+			// start := time.Now()
 			rw := &responseWriter{w, http.StatusOK}
 			next.ServeHTTP(rw, r)
 			// logger.Info("Request processed",
@@ -189,4 +393,4 @@ func RecoveryMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 		})
 	}
-}
\ No newline at end of file
+}